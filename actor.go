@@ -1,22 +1,39 @@
 package actor
 
 import (
+    "context"
+    "sync"
     "sync/atomic"
 )
 
 // Actor 表示一个基本的actor
 type Actor struct {
     id       string
+    kind     string // 远程创建时的类型名称，直接注册的actor为空
     mailbox  chan Message
-    handler  MessageHandler
+    handler  ContextHandler
     done     chan struct{}
     stopping atomic.Bool // 使用原子操作标记停止状态
+    sendMu   sync.RWMutex // Send持有读锁，Stop持有写锁关闭邮箱，避免向已关闭的邮箱发送
+    
+    // rejected 停止时邮箱中尚未处理的单向消息交给该函数，为nil时丢弃；在处理循环中调用，不能阻塞
+    rejected func(msg Message)
 }
 
 type MessageHandler func(msg interface{}) (interface{}, error)
 
+// ContextHandler 可以访问消息上下文的处理函数，例如通过 PeerFromContext 获取远程对端身份
+type ContextHandler func(ctx context.Context, msg interface{}) (interface{}, error)
+
 // NewActor 创建一个新的actor
 func NewActor(id string, handler MessageHandler) *Actor {
+    return NewContextActor(id, func(_ context.Context, msg interface{}) (interface{}, error) {
+        return handler(msg)
+    })
+}
+
+// NewContextActor 使用ContextHandler创建一个新的actor
+func NewContextActor(id string, handler ContextHandler) *Actor {
     return &Actor{
         id:      id,
         mailbox: make(chan Message, 100),
@@ -39,9 +56,11 @@ func (a *Actor) Start() {
         for msg := range a.mailbox {
             if a.stopping.Load() {
                 // Actor 正在停止，拒绝新消息
-                if msg.ReplyTo != nil {
-                    msg.ReplyTo <- Response{Error: ErrActorStopped.Error()}
+                if !msg.expectsReply() && a.rejected != nil {
+                    a.rejected(msg)
+                    continue
                 }
+                msg.reply(Response{Error: toError(ErrActorStopped)})
                 continue
             }
             
@@ -55,39 +74,46 @@ func (a *Actor) handleMessage(msg Message) {
     if msg.Context != nil {
         select {
         case <-msg.Context.Done():
-            if msg.ReplyTo != nil {
-                msg.ReplyTo <- Response{Error: msg.Context.Err().Error()}
-            }
+            msg.reply(Response{Error: toError(msg.Context.Err())})
             return
         default:
         }
     }
     
-    result, err := a.handler(msg.Payload)
-    if msg.ReplyTo == nil {
+    ctx := msg.Context
+    if ctx == nil {
+        ctx = context.Background()
+    }
+    result, err := a.handler(ctx, msg.Payload)
+    if !msg.expectsReply() {
         return // 单向消息，忽略结果
     }
     
     if err != nil {
-        msg.ReplyTo <- Response{Error: err.Error()}
+        msg.reply(Response{Error: toError(err)})
         return
     }
     
-    msg.ReplyTo <- Response{Data: result}
+    msg.reply(Response{Data: result})
 }
 
 // Stop 停止actor
 func (a *Actor) Stop() {
+    a.sendMu.Lock()
     if !a.stopping.CompareAndSwap(false, true) {
+        a.sendMu.Unlock()
         return // 已经在停止过程中
     }
-    
     close(a.mailbox)
+    a.sendMu.Unlock()
+    
     <-a.done // 等待所有消息处理完成
 }
 
 // Send 发送消息给actor
 func (a *Actor) Send(msg Message) error {
+    a.sendMu.RLock()
+    defer a.sendMu.RUnlock()
     if a.stopping.Load() {
         return ErrActorStopped
     }
@@ -95,9 +121,7 @@ func (a *Actor) Send(msg Message) error {
     if msg.Context != nil {
         select {
         case <-msg.Context.Done():
-            if msg.ReplyTo != nil {
-                msg.ReplyTo <- Response{Error: msg.Context.Err().Error()}
-            }
+            msg.reply(Response{Error: toError(msg.Context.Err())})
             return msg.Context.Err()
         case a.mailbox <- msg:
             return nil
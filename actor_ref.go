@@ -3,7 +3,6 @@ package actor
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 )
 
 // localActorRef 表示本地actor的引用
@@ -25,8 +24,8 @@ func (r *localActorRef) Request(ctx context.Context, req interface{}, resp inter
 
 	select {
 	case res := <-replyChan:
-		if res.Error != "" {
-			return fmt.Errorf(res.Error)
+		if res.Error != nil {
+			return res.Error
 		}
 		
 		// 将响应数据复制到resp
@@ -48,6 +47,58 @@ func (r *localActorRef) Tell(msg interface{}) error {
 	})
 }
 
+func (r *localActorRef) TellWithAck(ctx context.Context, msg interface{}) error {
+	return r.actor.Send(Message{
+		Payload: msg,
+		Context: ctx,
+	})
+}
+
+func (r *localActorRef) RequestStream(ctx context.Context, req interface{}) (*Stream, error) {
+	st := newStream(ctx, DefaultStreamWindow)
+	err := r.actor.Send(Message{
+		Payload: req,
+		Context: withResponseStream(st.ctx, &localResponseStream{stream: st}),
+		onReply: func(res Response) {
+			if res.Error != nil {
+				st.end(res.Error)
+				return
+			}
+			if res.Data != nil {
+				if err := st.push(res.Data); err != nil {
+					st.end(err)
+					return
+				}
+			}
+			st.end(nil)
+		},
+	})
+	if err != nil {
+		st.end(err)
+		return nil, err
+	}
+	return st, nil
+}
+
+func (r *localActorRef) RequestUpload(ctx context.Context) (UploadStream, error) {
+	st := newUploadInbox(ctx, DefaultStreamWindow, nil)
+	reply := make(chan Response, 1)
+	err := r.actor.Send(Message{
+		Payload: st,
+		Context: ctx,
+		onReply: func(res Response) {
+			// actor回复后不再接收分块
+			st.end(ErrStreamClosed)
+			reply <- res
+		},
+	})
+	if err != nil {
+		st.end(err)
+		return nil, err
+	}
+	return &localUploadStream{ctx: ctx, stream: st, reply: reply}, nil
+}
+
 func (r *localActorRef) ID() string {
 	return r.id
 }
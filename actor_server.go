@@ -1,11 +1,11 @@
 package actor
 
 import (
-	"bufio"
-	"encoding/json"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"sync"
+	"sync/atomic"
 )
 
 type ActorServer struct {
@@ -13,27 +13,36 @@ type ActorServer struct {
 	listener net.Listener
 	conns    sync.WaitGroup
 
-	maxWorkers int
-	taskQueue  chan func()
+	maxInFlight int // 全局同时处理中的请求上限
+	inFlight    atomic.Int64
+	opts        serverOptions
+	stats       linkStats
+
+	activeMu sync.Mutex
+	active   map[*serverConn]struct{}
+
+	dedup *deduplicator
+	kinds kindRegistry
 }
 
-func NewActorServer(sys *ActorSystem, maxWorkers int) *ActorServer {
-	if maxWorkers <= 0 {
-		maxWorkers = 100 // 默认值
+// NewActorServer 创建服务端，maxInFlight 限制所有连接同时等待actor响应的请求数，
+// 超过上限的请求立即返回 ErrServerBusy
+func NewActorServer(sys *ActorSystem, maxInFlight int, opts ...ServerOption) *ActorServer {
+	if maxInFlight <= 0 {
+		maxInFlight = 100 // 默认值
 	}
 
 	server := &ActorServer{
-		sys:        sys,
-		maxWorkers: maxWorkers,
-		taskQueue:  make(chan func(), maxWorkers),
+		sys:         sys,
+		maxInFlight: maxInFlight,
+		opts:        defaultServerOptions(),
+		active:      make(map[*serverConn]struct{}),
 	}
-
-	for i := 0; i < maxWorkers; i++ {
-		go func() {
-			for task := range server.taskQueue {
-				task()
-			}
-		}()
+	for _, opt := range opts {
+		opt(&server.opts)
+	}
+	if server.opts.dedupWindow > 0 {
+		server.dedup = newDeduplicator(server.opts.dedupWindow)
 	}
 
 	return server
@@ -44,12 +53,12 @@ func (s *ActorServer) Serve(addr string) error {
 	if err != nil {
 		return fmt.Errorf("failed to start listener: %w", err)
 	}
-	s.listener = listener
-
-	go s.acceptLoop()
-	return nil
+	return s.ServeListener(listener)
 }
 func (s *ActorServer) ServeListener(listener net.Listener) error {
+	if s.opts.tls != nil {
+		listener = tls.NewListener(listener, s.opts.tls)
+	}
 	s.listener = listener
 
 	go s.acceptLoop()
@@ -73,86 +82,69 @@ func (s *ActorServer) acceptLoop() {
 }
 
 func (s *ActorServer) handleConnection(conn net.Conn) {
-	reader := bufio.NewReaderSize(conn, 32*1024)
-	writer := bufio.NewWriterSize(conn, 32*1024)
-	dec := json.NewDecoder(reader)
-	enc := json.NewEncoder(writer)
-	var encMu sync.Mutex
+	sc, err := newServerConn(s, conn)
+	if err != nil {
+		return
+	}
 
-	for {
-		var msg struct {
-			Id      int64       `json:"id"`
-			Target  string      `json:"target"`
-			Type    MessageType `json:"type"`
-			Payload interface{} `json:"payload"`
-		}
+	s.activeMu.Lock()
+	s.active[sc] = struct{}{}
+	s.activeMu.Unlock()
+	defer func() {
+		s.activeMu.Lock()
+		delete(s.active, sc)
+		s.activeMu.Unlock()
+	}()
 
-		if err := dec.Decode(&msg); err != nil {
-			return
-		}
+	sc.serve()
+}
 
-		switch msg.Type {
-		case MessageTypeRequest:
-			select {
-			case s.taskQueue <- func() {
-				respCh := make(chan Response, 1)
-				defer close(respCh)
-
-				err := s.sys.SendMessage(msg.Target, Message{
-					Payload: msg.Payload,
-					ReplyTo: respCh,
-				})
-
-				encMu.Lock()
-				if err != nil {
-					enc.Encode(struct {
-						Id    int64  `json:"id"`
-						Error string `json:"error"`
-					}{
-						Id:    msg.Id,
-						Error: err.Error(),
-					})
-					encMu.Unlock()
-					return
-				}
-
-				resp := <-respCh
-				enc.Encode(struct {
-					Id      int64       `json:"id"`
-					Error   string      `json:"error"`
-					Payload interface{} `json:"payload"`
-				}{
-					Id:      msg.Id,
-					Error:   resp.Error,
-					Payload: resp.Data,
-				})
-				encMu.Unlock()
-			}:
-			default:
-				encMu.Lock()
-				enc.Encode(struct {
-					Id    int64  `json:"id"`
-					Error string `json:"error"`
-				}{
-					Id:    msg.Id,
-					Error: "server is busy",
-				})
-				encMu.Unlock()
-			}
-
-		case MessageTypeTell:
-			s.sys.SendMessage(msg.Target, Message{
-				Payload: msg.Payload,
-			})
-		}
+// Addr 返回监听地址，未开始监听时返回nil
+func (s *ActorServer) Addr() net.Addr {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// NodeID 返回服务端在握手中声明的节点标识
+func (s *ActorServer) NodeID() string {
+	return s.opts.nodeID
+}
+
+// acquire 占用一个全局请求配额
+func (s *ActorServer) acquire() bool {
+	if s.inFlight.Add(1) > int64(s.maxInFlight) {
+		s.inFlight.Add(-1)
+		return false
 	}
+	return true
+}
+
+// release 归还一个全局请求配额
+func (s *ActorServer) release() {
+	s.inFlight.Add(-1)
+}
+
+// InFlight 返回当前等待actor响应的请求数
+func (s *ActorServer) InFlight() int {
+	return int(s.inFlight.Load())
+}
+
+// Stats 返回服务端所有连接的累计流量统计
+func (s *ActorServer) Stats() LinkStats {
+	return s.stats.snapshot()
 }
 
 func (s *ActorServer) Stop() error {
-	close(s.taskQueue)
 	if s.listener != nil {
 		s.listener.Close()
 	}
+	s.activeMu.Lock()
+	for sc := range s.active {
+		sc.conn.Close()
+	}
+	s.activeMu.Unlock()
 	s.conns.Wait()
 	return nil
 }
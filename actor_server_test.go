@@ -0,0 +1,90 @@
+package actor
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSlowHandlersDoNotBlockServer(t *testing.T) {
+	sys := NewActorSystem()
+	release := make(chan struct{})
+	started := make(chan struct{}, 20)
+	for i := 0; i < 20; i++ {
+		if _, err := sys.RegisterActor(fmt.Sprint("slow", i), func(msg interface{}) (interface{}, error) {
+			started <- struct{}{}
+			<-release
+			return "slow", nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := sys.RegisterActor("fast", echo); err != nil {
+		t.Fatal(err)
+	}
+	// 全局配额大于慢请求数，慢请求只占用配额而不占用处理协程
+	srv, addr := startServer(t, sys)
+	ctx := testContext(t)
+
+	conn := dial(t, "", addr)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		ref := &selectedRemoteRef{conn: conn.(*remoteActorRef), id: fmt.Sprint("slow", i)}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var out string
+			if err := ref.Request(ctx, "x", &out); err != nil || out != "slow" {
+				t.Error(out, err)
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		<-started
+	}
+	if n := srv.InFlight(); n != 20 {
+		t.Fatalf("%d requests in flight, want 20", n)
+	}
+
+	var out string
+	fast := &selectedRemoteRef{conn: conn.(*remoteActorRef), id: "fast"}
+	start := time.Now()
+	if err := fast.Request(ctx, "hi", &out); err != nil || out != "hi" {
+		t.Fatal(out, err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("fast request took %v behind slow handlers", d)
+	}
+	close(release)
+	wg.Wait()
+	eventually(t, "in-flight quota released", func() bool { return srv.InFlight() == 0 })
+}
+
+func TestMaxInFlightPerConn(t *testing.T) {
+	sys := NewActorSystem()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	if _, err := sys.RegisterActor("slow", func(msg interface{}) (interface{}, error) {
+		close(started)
+		<-release
+		return nil, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	_, addr := startServer(t, sys, WithMaxInFlightPerConn(1))
+	ctx := testContext(t)
+	ref := dial(t, "slow", addr)
+
+	done := make(chan error, 1)
+	go func() { done <- ref.Request(ctx, "x", new(int)) }()
+	<-started
+	if err := ref.Request(ctx, "x", new(int)); !errors.Is(err, ErrServerBusy) {
+		t.Fatalf("second request: %v, want ErrServerBusy", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,181 @@
+package actor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+)
+
+var (
+	ErrUnauthenticated  = errors.New("unauthenticated")
+	ErrPermissionDenied = errors.New("permission denied")
+)
+
+// 认证方式
+const (
+	AuthMethodToken = "token"
+	AuthMethodHMAC  = "hmac"
+	AuthMethodMTLS  = "mtls"
+)
+
+// Principal 表示认证后的调用方身份
+type Principal struct {
+	Name   string // 身份名称
+	Method string // 认证方式
+}
+
+// AuthRequest 客户端在握手阶段提交的认证信息
+type AuthRequest struct {
+	Method   string `json:"method,omitempty"`
+	Identity string `json:"identity,omitempty"`
+	Proof    []byte `json:"proof,omitempty"`
+}
+
+// authResult 服务端认证结果
+type authResult struct {
+	Principal string `json:"principal,omitempty"`
+	Error     *Error `json:"error,omitempty"`
+}
+
+// Credentials 客户端凭证，根据服务端下发的挑战生成认证信息
+type Credentials interface {
+	Authenticate(challenge []byte) (*AuthRequest, error)
+}
+
+// Authenticator 服务端认证器，在连接握手阶段执行
+type Authenticator interface {
+	Authenticate(peer *Peer, challenge []byte, req *AuthRequest) (*Principal, error)
+}
+
+// Authorizer 判断调用方是否可以访问指定的actor
+type Authorizer interface {
+	Authorize(p *Principal, target string) error
+}
+
+// tokenCredentials 共享令牌凭证
+type tokenCredentials struct {
+	identity string
+	token    string
+}
+
+// TokenCredentials 创建共享令牌凭证
+func TokenCredentials(identity, token string) Credentials {
+	return &tokenCredentials{identity: identity, token: token}
+}
+
+func (c *tokenCredentials) Authenticate(challenge []byte) (*AuthRequest, error) {
+	return &AuthRequest{Method: AuthMethodToken, Identity: c.identity, Proof: []byte(c.token)}, nil
+}
+
+// hmacCredentials HMAC挑战应答凭证，密钥本身不会在网络上传输
+type hmacCredentials struct {
+	identity string
+	secret   []byte
+}
+
+// HMACCredentials 创建HMAC挑战应答凭证
+func HMACCredentials(identity string, secret []byte) Credentials {
+	return &hmacCredentials{identity: identity, secret: secret}
+}
+
+func (c *hmacCredentials) Authenticate(challenge []byte) (*AuthRequest, error) {
+	return &AuthRequest{Method: AuthMethodHMAC, Identity: c.identity, Proof: hmacSign(c.secret, challenge)}, nil
+}
+
+func hmacSign(secret, challenge []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(challenge)
+	return mac.Sum(nil)
+}
+
+// TokenAuthenticator 按身份校验共享令牌
+type TokenAuthenticator map[string]string
+
+func (a TokenAuthenticator) Authenticate(_ *Peer, _ []byte, req *AuthRequest) (*Principal, error) {
+	if req == nil || req.Method != AuthMethodToken {
+		return nil, ErrUnauthenticated
+	}
+	token, ok := a[req.Identity]
+	if !ok || subtle.ConstantTimeCompare([]byte(token), req.Proof) != 1 {
+		return nil, ErrUnauthenticated
+	}
+	return &Principal{Name: req.Identity, Method: AuthMethodToken}, nil
+}
+
+// HMACAuthenticator 按身份校验HMAC挑战应答
+type HMACAuthenticator map[string][]byte
+
+func (a HMACAuthenticator) Authenticate(_ *Peer, challenge []byte, req *AuthRequest) (*Principal, error) {
+	if req == nil || req.Method != AuthMethodHMAC {
+		return nil, ErrUnauthenticated
+	}
+	secret, ok := a[req.Identity]
+	if !ok || !hmac.Equal(hmacSign(secret, challenge), req.Proof) {
+		return nil, ErrUnauthenticated
+	}
+	return &Principal{Name: req.Identity, Method: AuthMethodHMAC}, nil
+}
+
+// MTLSAuthenticator 使用已验证的客户端证书CN作为身份
+type MTLSAuthenticator struct{}
+
+func (MTLSAuthenticator) Authenticate(peer *Peer, _ []byte, _ *AuthRequest) (*Principal, error) {
+	if peer == nil || !peer.Verified() {
+		return nil, ErrUnauthenticated
+	}
+	return &Principal{Name: peer.CommonName(), Method: AuthMethodMTLS}, nil
+}
+
+// MultiAuthenticator 依次尝试多个认证器，任意一个通过即认证成功
+type MultiAuthenticator []Authenticator
+
+func (m MultiAuthenticator) Authenticate(peer *Peer, challenge []byte, req *AuthRequest) (*Principal, error) {
+	for _, a := range m {
+		if p, err := a.Authenticate(peer, challenge, req); err == nil {
+			return p, nil
+		}
+	}
+	return nil, ErrUnauthenticated
+}
+
+// ACL 将身份映射到其可访问的actor id或通配模式(path.Match语法)
+type ACL struct {
+	mu    sync.RWMutex
+	rules map[string][]string
+}
+
+// NewACL 创建一个空的访问控制列表，默认拒绝所有访问
+func NewACL() *ACL {
+	return &ACL{rules: make(map[string][]string)}
+}
+
+// Allow 允许身份访问匹配模式的actor，身份为"*"时对所有已认证身份生效
+func (a *ACL) Allow(principal string, patterns ...string) *ACL {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.rules[principal] = append(a.rules[principal], patterns...)
+	return a
+}
+
+// Authorize 实现Authorizer
+func (a *ACL) Authorize(p *Principal, target string) error {
+	name := ""
+	if p != nil {
+		name = p.Name
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for _, key := range []string{name, "*"} {
+		for _, pattern := range a.rules[key] {
+			if ok, _ := path.Match(pattern, target); ok {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: %s may not access %s", ErrPermissionDenied, name, target)
+}
@@ -0,0 +1,90 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestACLAuthorize(t *testing.T) {
+	acl := NewACL().Allow("*", "pub/*").Allow("alice", "admin", "users/*")
+	tests := []struct {
+		principal string
+		target    string
+		allowed   bool
+	}{
+		{"bob", "pub/a", true},
+		{"bob", "admin", false},
+		{"alice", "admin", true},
+		{"alice", "users/1", true},
+		{"alice", "users/1/x", false},
+		{"", "pub/a", true},
+		{"", "admin", false},
+	}
+	for _, tt := range tests {
+		err := acl.Authorize(&Principal{Name: tt.principal}, tt.target)
+		if allowed := err == nil; allowed != tt.allowed {
+			t.Errorf("%q -> %q: allowed=%v, want %v (%v)", tt.principal, tt.target, allowed, tt.allowed, err)
+		}
+		if err != nil && !errors.Is(err, ErrPermissionDenied) {
+			t.Errorf("%q -> %q: %v is not ErrPermissionDenied", tt.principal, tt.target, err)
+		}
+	}
+}
+
+func TestRemoteAuthentication(t *testing.T) {
+	sys := NewActorSystem()
+	if _, err := sys.RegisterContextActor("pub/whoami", func(ctx context.Context, msg interface{}) (interface{}, error) {
+		p, _ := PeerFromContext(ctx)
+		return p.Principal.Name, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sys.RegisterActor("admin", echo); err != nil {
+		t.Fatal(err)
+	}
+	_, addr := startServer(t, sys,
+		WithAuthenticator(MultiAuthenticator{
+			TokenAuthenticator{"bob": "t0k"},
+			HMACAuthenticator{"alice": []byte("s3cret")},
+		}),
+		WithAuthorizer(NewACL().Allow("*", "pub/*").Allow("alice", "admin")))
+	ctx := testContext(t)
+
+	tests := []struct {
+		name    string
+		creds   Credentials
+		target  string
+		dialErr error
+		reqErr  error
+		want    string
+	}{
+		{"no credentials", nil, "pub/whoami", ErrUnauthenticated, nil, ""},
+		{"wrong token", TokenCredentials("bob", "bad"), "pub/whoami", ErrUnauthenticated, nil, ""},
+		{"wrong hmac secret", HMACCredentials("alice", []byte("nope")), "pub/whoami", ErrUnauthenticated, nil, ""},
+		{"token", TokenCredentials("bob", "t0k"), "pub/whoami", nil, nil, "bob"},
+		{"hmac", HMACCredentials("alice", []byte("s3cret")), "pub/whoami", nil, nil, "alice"},
+		{"denied by acl", TokenCredentials("bob", "t0k"), "admin", nil, ErrPermissionDenied, ""},
+		{"allowed by acl", HMACCredentials("alice", []byte("s3cret")), "admin", nil, nil, "hi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []RemoteOption
+			if tt.creds != nil {
+				opts = append(opts, WithCredentials(tt.creds))
+			}
+			ref, err := NewRemoteActorRef(tt.target, addr, opts...)
+			if !errors.Is(err, tt.dialErr) {
+				t.Fatalf("dial: %v, want %v", err, tt.dialErr)
+			}
+			if err != nil {
+				return
+			}
+			defer ref.(*remoteActorRef).Close()
+			var out string
+			if err := ref.Request(ctx, "hi", &out); !errors.Is(err, tt.reqErr) || out != tt.want {
+				t.Fatalf("request: %q %v, want %q %v", out, err, tt.want, tt.reqErr)
+			}
+		})
+	}
+}
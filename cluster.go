@@ -0,0 +1,539 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// clusterActorID 集群成员之间交换gossip的系统actor
+const clusterActorID = "$cluster"
+
+// DefaultGossipInterval 默认的gossip周期
+const DefaultGossipInterval = time.Second
+
+// ErrClusterStopped 集群节点已停止
+var ErrClusterStopped = errors.New("cluster node is stopped")
+
+// MemberStatus 集群成员状态，按 joining → up → leaving → down → removed 的顺序推进，
+// 被误判为down或removed的成员以更高的版本恢复为存活状态
+type MemberStatus int
+
+const (
+	MemberJoining MemberStatus = iota + 1
+	MemberUp
+	MemberLeaving
+	MemberDown
+	MemberRemoved
+)
+
+func (s MemberStatus) String() string {
+	switch s {
+	case MemberJoining:
+		return "joining"
+	case MemberUp:
+		return "up"
+	case MemberLeaving:
+		return "leaving"
+	case MemberDown:
+		return "down"
+	case MemberRemoved:
+		return "removed"
+	}
+	return "unknown"
+}
+
+// Member 集群成员
+type Member struct {
+	NodeID    string       `json:"node_id"`
+	Address   string       `json:"address"`
+	Status    MemberStatus `json:"status"`
+	Version   uint64       `json:"version"`   // 状态版本，版本相同时更靠后的状态生效
+	Heartbeat uint64       `json:"heartbeat"` // 成员每个gossip周期自增，用于失效检测
+	Joined    int64        `json:"joined"`    // 加入时间(UnixNano)，用于确定最老的成员
+}
+
+// alive 成员是否仍参与集群
+func (m Member) alive() bool {
+	return m.Status == MemberJoining || m.Status == MemberUp || m.Status == MemberLeaving
+}
+
+// newer 判断m的状态是否比other新
+func (m Member) newer(other Member) bool {
+	if m.Version != other.Version {
+		return m.Version > other.Version
+	}
+	return m.Status > other.Status
+}
+
+// MemberEvent 成员状态变化事件，发布在 ActorSystem.EventStream 上
+type MemberEvent struct {
+	Member   Member
+	Previous MemberStatus // 首次发现该成员时为0
+}
+
+// LeaderChanged 本节点视角下的leader变化事件，发布在 ActorSystem.EventStream 上
+type LeaderChanged struct {
+	Leader string // leader地址，没有存活成员时为空
+}
+
+// ClusterConfig 集群节点配置
+type ClusterConfig struct {
+	Address         string           // 其他节点连接本节点使用的地址，默认为服务端监听地址
+	Seeds           []string         // 种子节点地址，本节点尚未发现其他成员时向种子节点发送gossip
+	GossipInterval  time.Duration    // gossip周期，默认1s
+	FailureDetector *FailureDetector // 按成员心跳计数判定失效，默认根据gossip周期创建
+	RemoteOptions   []RemoteOption   // 连接其他节点时使用的选项
+}
+
+// gossipState 节点之间交换的成员表
+type gossipState struct {
+	From    string
+	Members []Member
+}
+
+func init() {
+	RegisterMessageType[gossipState]("actor.clusterGossip")
+}
+
+// Cluster 基于gossip的集群成员管理
+// 每个周期向一个随机成员发送完整成员表并合并对方的回复；
+// 心跳计数长时间没有增长的成员在本节点被视为不可达，地址最小的可达存活成员作为leader，
+// 负责将不可达成员标记为down，将joining推进为up，将leaving与down推进为removed；
+// 仍在运行的成员收到自身被标记为down或removed的状态时，以更高的版本反驳并恢复
+type Cluster struct {
+	sys  *ActorSystem
+	cfg  ClusterConfig
+	self string // 本节点的节点标识
+	fd   *FailureDetector
+
+	mu      sync.Mutex
+	members map[string]*Member
+	leader  string
+	joined  bool // 本节点是第一个种子节点，或已与其他成员交换过gossip
+	leaving bool // 已调用 Leave，不再反驳自身的down与removed状态
+
+	unreachable map[string]bool // 本节点判定不可达的成员，只在本地生效，不随gossip传播
+
+	conns *connPool
+
+	gossiping atomic.Bool
+	started   atomic.Bool
+	done      chan struct{}
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewCluster 为sys与srv创建集群节点，srv需已开始监听，调用 Start 后加入集群
+func NewCluster(sys *ActorSystem, srv *ActorServer, cfg ClusterConfig) (*Cluster, error) {
+	if cfg.Address == "" {
+		addr := srv.Addr()
+		if addr == nil {
+			return nil, fmt.Errorf("cluster: server is not listening")
+		}
+		cfg.Address = addr.String()
+	}
+	if cfg.GossipInterval <= 0 {
+		cfg.GossipInterval = DefaultGossipInterval
+	}
+	fd := cfg.FailureDetector
+	if fd == nil {
+		// 心跳经由随机gossip传播，到达间隔波动较大
+		fd = NewFailureDetector(FailureDetectorConfig{
+			FirstHeartbeatEstimate: cfg.GossipInterval * 2,
+			MinStdDeviation:        cfg.GossipInterval,
+			AcceptablePause:        cfg.GossipInterval * 2,
+		})
+	}
+
+	c := &Cluster{
+		sys:     sys,
+		cfg:     cfg,
+		self:    srv.NodeID(),
+		fd:      fd,
+		members: make(map[string]*Member),
+		done:    make(chan struct{}),
+
+		unreachable: make(map[string]bool),
+	}
+	c.conns = newConnPool(append([]RemoteOption{WithDialTimeout(cfg.GossipInterval)}, cfg.RemoteOptions...))
+	self := &Member{
+		NodeID:  c.self,
+		Address: cfg.Address,
+		Status:  MemberJoining,
+		Version: 1,
+		Joined:  time.Now().UnixNano(),
+	}
+	c.members[c.self] = self
+	// 第一个种子节点可以独自组成集群，其他节点需先联系到已有成员，避免各自成为leader
+	c.joined = len(cfg.Seeds) == 0 || cfg.Seeds[0] == cfg.Address
+
+	if _, err := sys.RegisterContextActor(clusterActorID, c.receive); err != nil {
+		return nil, fmt.Errorf("cluster: %w", err)
+	}
+	return c, nil
+}
+
+// Start 开始gossip并通过种子节点加入集群
+func (c *Cluster) Start() {
+	if !c.started.CompareAndSwap(false, true) {
+		return
+	}
+	c.mu.Lock()
+	self := *c.members[c.self]
+	events := c.updateLeader()
+	c.mu.Unlock()
+	c.publish(append([]interface{}{MemberEvent{Member: self}}, events...))
+
+	c.wg.Add(1)
+	go c.loop()
+}
+
+// Self 返回本节点的成员信息
+func (c *Cluster) Self() Member {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return *c.members[c.self]
+}
+
+// Members 返回未移除的成员，按地址排序
+func (c *Cluster) Members() []Member {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	members := make([]Member, 0, len(c.members))
+	for _, m := range c.members {
+		if m.Status != MemberRemoved {
+			members = append(members, *m)
+		}
+	}
+	sortMembers(members)
+	return members
+}
+
+// Leader 返回本节点视角下的leader地址
+func (c *Cluster) Leader() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.leader
+}
+
+// Leave 通知集群本节点即将离开，等待leader将本节点移除后停止
+func (c *Cluster) Leave(ctx context.Context) error {
+	c.mu.Lock()
+	self := c.members[c.self]
+	c.leaving = true
+	var events []interface{}
+	if self.Status == MemberJoining || self.Status == MemberUp {
+		prev := self.Status
+		self.Status = MemberLeaving
+		self.Version++
+		events = append(events, MemberEvent{Member: *self, Previous: prev})
+	}
+	c.mu.Unlock()
+	c.publish(events)
+
+	ticker := time.NewTicker(c.cfg.GossipInterval / 2)
+	defer ticker.Stop()
+	for {
+		if s := c.Self().Status; s == MemberRemoved || s == MemberDown {
+			break
+		}
+		select {
+		case <-ticker.C:
+		case <-c.done:
+			return ErrClusterStopped
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	// 本节点作为leader移除自身时，需要把最终状态告知其他成员
+	c.mu.Lock()
+	state := c.stateLocked()
+	targets := c.peersLocked()
+	c.mu.Unlock()
+	for i, addr := range targets {
+		if i >= 3 {
+			break
+		}
+		c.gossip(addr, state)
+	}
+	c.Stop()
+	return nil
+}
+
+// Stop 停止gossip并释放连接，不通知其他成员，其他成员随后将本节点判定为down
+func (c *Cluster) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.done)
+		c.wg.Wait()
+		c.sys.DeregisterActor(clusterActorID)
+
+		c.conns.close()
+	})
+}
+
+func (c *Cluster) loop() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(c.cfg.GossipInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.tick()
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// tick 执行一个gossip周期
+func (c *Cluster) tick() {
+	c.mu.Lock()
+	self := c.members[c.self]
+	if !self.alive() {
+		c.mu.Unlock()
+		return // 已离开集群
+	}
+	self.Heartbeat++
+	c.detectFailures()
+	events := c.updateLeader()
+	if c.leader == self.Address {
+		events = append(events, c.leaderActions()...)
+		events = append(events, c.updateLeader()...)
+	}
+	state := c.stateLocked()
+	target := c.pickTarget()
+	c.mu.Unlock()
+	c.publish(events)
+
+	// 上一次gossip尚未完成时跳过，避免慢节点拖住心跳
+	if target != "" && c.gossiping.CompareAndSwap(false, true) {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			defer c.gossiping.Store(false)
+			c.gossip(target, state)
+		}()
+	}
+}
+
+// detectFailures 记录心跳停止增长的成员，单个节点的判断不直接改变成员状态，由leader标记为down
+func (c *Cluster) detectFailures() {
+	for id, m := range c.members {
+		if id != c.self && m.alive() && !c.fd.IsAvailable(id) {
+			c.unreachable[id] = true
+		} else {
+			delete(c.unreachable, id)
+		}
+	}
+}
+
+// leaderActions 推进成员状态
+func (c *Cluster) leaderActions() []interface{} {
+	var events []interface{}
+	for _, m := range c.members {
+		prev := m.Status
+		switch {
+		case c.unreachable[m.NodeID] && m.alive():
+			m.Status = MemberDown
+			delete(c.unreachable, m.NodeID)
+		case m.Status == MemberJoining:
+			m.Status = MemberUp
+		case m.Status == MemberLeaving || m.Status == MemberDown:
+			m.Status = MemberRemoved
+		default:
+			continue
+		}
+		m.Version++
+		if m.NodeID != c.self {
+			c.fd.Remove(m.NodeID)
+			if m.Status == MemberUp {
+				c.fd.Heartbeat(m.NodeID)
+			}
+		}
+		events = append(events, MemberEvent{Member: *m, Previous: prev})
+	}
+	return events
+}
+
+// updateLeader 重新计算leader，变化时返回 LeaderChanged 事件
+func (c *Cluster) updateLeader() []interface{} {
+	if !c.joined {
+		return nil
+	}
+	leader := ""
+	var best *Member
+	for _, m := range c.members {
+		if !m.alive() || c.unreachable[m.NodeID] {
+			continue
+		}
+		if best == nil || m.Address < best.Address || (m.Address == best.Address && m.NodeID < best.NodeID) {
+			best = m
+		}
+	}
+	if best != nil {
+		leader = best.Address
+	}
+	if leader == c.leader {
+		return nil
+	}
+	c.leader = leader
+	return []interface{}{LeaderChanged{Leader: leader}}
+}
+
+// peersLocked 返回其他存活成员的地址
+func (c *Cluster) peersLocked() []string {
+	var addrs []string
+	for id, m := range c.members {
+		if id != c.self && m.alive() {
+			addrs = append(addrs, m.Address)
+		}
+	}
+	rand.Shuffle(len(addrs), func(i, j int) {
+		addrs[i], addrs[j] = addrs[j], addrs[i]
+	})
+	return addrs
+}
+
+// pickTarget 随机选择一个gossip对象，尚未发现其他成员时选择种子节点
+func (c *Cluster) pickTarget() string {
+	if peers := c.peersLocked(); len(peers) > 0 {
+		return peers[0]
+	}
+	self := c.members[c.self].Address
+	var seeds []string
+	for _, s := range c.cfg.Seeds {
+		if s != self {
+			seeds = append(seeds, s)
+		}
+	}
+	if len(seeds) == 0 {
+		return ""
+	}
+	return seeds[rand.Intn(len(seeds))]
+}
+
+func (c *Cluster) stateLocked() gossipState {
+	state := gossipState{From: c.self, Members: make([]Member, 0, len(c.members))}
+	for _, m := range c.members {
+		state.Members = append(state.Members, *m)
+	}
+	return state
+}
+
+// gossip 向address发送成员表并合并对方的回复
+func (c *Cluster) gossip(address string, state gossipState) {
+	ref, err := c.conns.ref(address, clusterActorID)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.cfg.GossipInterval*2)
+	defer cancel()
+	var reply gossipState
+	if err := ref.Request(ctx, state, &reply); err != nil {
+		c.conns.drop(address, ref)
+		return
+	}
+	c.merge(reply)
+}
+
+// receive 处理其他节点发来的gossip，回复本节点的成员表
+func (c *Cluster) receive(_ context.Context, msg interface{}) (interface{}, error) {
+	state, ok := msg.(gossipState)
+	if !ok {
+		return nil, fmt.Errorf("cluster: unexpected message %T", msg)
+	}
+	c.merge(state)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stateLocked(), nil
+}
+
+// merge 合并其他节点的成员表
+func (c *Cluster) merge(state gossipState) {
+	var events []interface{}
+	c.mu.Lock()
+	if state.From != c.self {
+		c.joined = true
+	}
+	for _, m := range state.Members {
+		local, ok := c.members[m.NodeID]
+		if !ok {
+			if m.Status == MemberRemoved {
+				// 只记录墓碑，防止旧状态复活
+				mm := m
+				c.members[m.NodeID] = &mm
+				continue
+			}
+			mm := m
+			c.members[m.NodeID] = &mm
+			if mm.alive() {
+				c.fd.Heartbeat(mm.NodeID)
+			}
+			events = append(events, MemberEvent{Member: mm})
+			continue
+		}
+
+		if m.NodeID != c.self && m.Heartbeat > local.Heartbeat {
+			local.Heartbeat = m.Heartbeat
+			if local.alive() {
+				c.fd.Heartbeat(local.NodeID)
+			}
+		}
+		if !m.newer(*local) {
+			continue
+		}
+		prev, wasAlive := local.Status, local.alive()
+		if local.NodeID == c.self {
+			if !m.alive() && !c.leaving {
+				// 其他成员误判本节点失效，以更高的版本反驳；已被移除时需要重新加入
+				local.Version = m.Version + 1
+				if m.Status == MemberRemoved {
+					local.Status = MemberJoining
+				}
+			} else {
+				local.Status = m.Status
+				local.Version = m.Version
+			}
+		} else {
+			local.Status = m.Status
+			local.Version = m.Version
+			switch {
+			case !local.alive():
+				c.fd.Remove(local.NodeID)
+				delete(c.unreachable, local.NodeID)
+			case !wasAlive:
+				// 被误判的成员恢复，重新开始失效检测
+				c.fd.Remove(local.NodeID)
+				c.fd.Heartbeat(local.NodeID)
+			}
+		}
+		if prev != local.Status {
+			events = append(events, MemberEvent{Member: *local, Previous: prev})
+		}
+	}
+	events = append(events, c.updateLeader()...)
+	c.mu.Unlock()
+	c.publish(events)
+}
+
+func (c *Cluster) publish(events []interface{}) {
+	for _, e := range events {
+		c.sys.events.Publish(e)
+	}
+}
+
+func sortMembers(members []Member) {
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].Address != members[j].Address {
+			return members[i].Address < members[j].Address
+		}
+		return members[i].NodeID < members[j].NodeID
+	})
+}
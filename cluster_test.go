@@ -0,0 +1,205 @@
+package actor
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testNode 回环地址上的集群节点，记录收到的成员事件
+type testNode struct {
+	sys  *ActorSystem
+	srv  *ActorServer
+	c    *Cluster
+	addr string
+
+	mu     sync.Mutex
+	events []string
+}
+
+// startNode 启动一个集群节点，seed为空时本节点作为种子节点
+func startNode(t *testing.T, seed string) *testNode {
+	t.Helper()
+	n := &testNode{sys: NewActorSystem()}
+	n.srv, n.addr = startServer(t, n.sys)
+	if seed == "" {
+		seed = n.addr
+	}
+	n.sys.EventStream().Subscribe(func(e interface{}) {
+		if e, ok := e.(MemberEvent); ok {
+			n.mu.Lock()
+			n.events = append(n.events, e.Member.Address+":"+e.Member.Status.String())
+			n.mu.Unlock()
+		}
+	})
+	c, err := NewCluster(n.sys, n.srv, ClusterConfig{Seeds: []string{seed}, GossipInterval: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.c = c
+	t.Cleanup(c.Stop)
+	c.Start()
+	return n
+}
+
+// crash 不通知其他成员直接停止节点
+func (n *testNode) crash() {
+	n.c.Stop()
+	_ = n.srv.Stop()
+}
+
+func (n *testNode) saw(address string, status MemberStatus) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, e := range n.events {
+		if e == address+":"+status.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// member 返回节点视角下address的成员信息
+func (n *testNode) member(address string) (Member, bool) {
+	for _, m := range n.c.Members() {
+		if m.Address == address {
+			return m, true
+		}
+	}
+	return Member{}, false
+}
+
+// allUp 判断每个节点都看到且只看到nodes中的成员，并且都是up
+func allUp(nodes []*testNode) bool {
+	for _, n := range nodes {
+		members := n.c.Members()
+		if len(members) != len(nodes) {
+			return false
+		}
+		for _, m := range members {
+			if m.Status != MemberUp {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// startCluster 启动size个节点并等待全部成为up
+func startCluster(t *testing.T, size int) []*testNode {
+	t.Helper()
+	nodes := []*testNode{startNode(t, "")}
+	for i := 1; i < size; i++ {
+		nodes = append(nodes, startNode(t, nodes[0].addr))
+	}
+	eventually(t, "all members up", func() bool { return allUp(nodes) })
+	return nodes
+}
+
+// byAddress 按地址排序，第一个节点即为leader
+func byAddress(nodes []*testNode) []*testNode {
+	sorted := append([]*testNode(nil), nodes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].addr < sorted[j].addr })
+	return sorted
+}
+
+func TestClusterMembership(t *testing.T) {
+	nodes := byAddress(startCluster(t, 4))
+	for _, n := range nodes {
+		if n.c.Leader() != nodes[0].addr {
+			t.Fatalf("%s sees leader %s, want %s", n.addr, n.c.Leader(), nodes[0].addr)
+		}
+	}
+	ctx := testContext(t)
+
+	// 正常离开
+	if err := nodes[3].c.Leave(ctx); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "member removed after leave", func() bool {
+		return allUp(nodes[:3]) && nodes[0].saw(nodes[3].addr, MemberRemoved)
+	})
+
+	// 崩溃的成员由leader标记为down后移除
+	nodes[2].crash()
+	eventually(t, "crashed member down", func() bool {
+		return nodes[0].saw(nodes[2].addr, MemberDown) && nodes[1].saw(nodes[2].addr, MemberDown)
+	})
+	eventually(t, "crashed member removed", func() bool { return allUp(nodes[:2]) })
+
+	// leader离开后由剩余成员接任
+	if err := nodes[0].c.Leave(ctx); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "leader handover", func() bool {
+		return nodes[1].c.Leader() == nodes[1].addr && allUp(nodes[1:2])
+	})
+}
+
+func TestClusterLeaderCrash(t *testing.T) {
+	nodes := byAddress(startCluster(t, 3))
+	nodes[0].crash()
+	eventually(t, "new leader after crash", func() bool {
+		return allUp(nodes[1:]) && nodes[1].c.Leader() == nodes[1].addr && nodes[2].c.Leader() == nodes[1].addr
+	})
+	if !nodes[2].saw(nodes[0].addr, MemberDown) && !nodes[1].saw(nodes[0].addr, MemberDown) {
+		t.Fatal("crashed leader was not marked down")
+	}
+}
+
+func TestClusterRefutesFalseSuspicion(t *testing.T) {
+	tests := []struct {
+		name   string
+		status MemberStatus
+	}{
+		{"down", MemberDown},
+		{"removed", MemberRemoved},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes := byAddress(startCluster(t, 3))
+			leader, victim := nodes[0], nodes[2]
+
+			// 模拟leader误判后传播的状态
+			suspected := victim.c.Self()
+			suspected.Status = tt.status
+			suspected.Version++
+			for _, n := range nodes {
+				n.c.merge(gossipState{From: leader.c.self, Members: []Member{suspected}})
+			}
+
+			if s := victim.c.Self(); !s.alive() || s.Version <= suspected.Version {
+				t.Fatalf("victim did not refute: %+v", s)
+			}
+			eventually(t, "victim up everywhere", func() bool {
+				for _, n := range nodes {
+					m, ok := n.member(victim.addr)
+					if !ok || m.Status != MemberUp || m.Version <= suspected.Version {
+						return false
+					}
+				}
+				return allUp(nodes)
+			})
+
+			// 恢复后仍参与gossip，心跳继续传播
+			before, _ := leader.member(victim.addr)
+			eventually(t, "victim heartbeats", func() bool {
+				m, _ := leader.member(victim.addr)
+				return m.Heartbeat > before.Heartbeat+3
+			})
+		})
+	}
+}
+
+func TestClusterLeavingNodeDoesNotRefute(t *testing.T) {
+	nodes := byAddress(startCluster(t, 2))
+	ctx := testContext(t)
+	if err := nodes[1].c.Leave(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if s := nodes[1].c.Self().Status; s != MemberRemoved && s != MemberDown {
+		t.Fatalf("leaving node refuted its removal: %s", s)
+	}
+	eventually(t, "leaving node removed", func() bool { return allUp(nodes[:1]) })
+}
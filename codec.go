@@ -0,0 +1,134 @@
+package actor
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// 内置编解码器名称
+const (
+	CodecJSON     = "json"
+	CodecGob      = "gob"
+	CodecMsgPack  = "msgpack"  // 预留，需通过 RegisterCodec 注册实现
+	CodecProtobuf = "protobuf" // 预留，需通过 RegisterCodec 注册实现
+)
+
+// Codec 定义远程消息的编解码方式
+type Codec interface {
+	// Name 编解码器名称，用于握手协商
+	Name() string
+
+	// Marshal 将值编码为字节
+	Marshal(v interface{}) ([]byte, error)
+
+	// Unmarshal 将字节解码到v
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{
+		CodecJSON: jsonCodec{},
+		CodecGob:  gobCodec{},
+	}
+)
+
+func init() {
+	// gob 需要预先注册通过 interface{} 传输的动态类型
+	gob.Register(map[string]interface{}{})
+	gob.Register([]interface{}{})
+}
+
+// RegisterCodec 注册一个编解码器，同名编解码器会被覆盖
+func RegisterCodec(c Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[c.Name()] = c
+}
+
+// GetCodec 按名称获取编解码器
+func GetCodec(name string) (Codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	c, ok := codecs[name]
+	return c, ok
+}
+
+// negotiateCodec 按客户端偏好顺序选择双方都支持的编解码器
+func negotiateCodec(offered []string, accepted []string) (Codec, error) {
+	for _, name := range offered {
+		if len(accepted) > 0 && !containsString(accepted, name) {
+			continue
+		}
+		if c, ok := GetCodec(name); ok {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("no common codec in %v", offered)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonCodec 基于 encoding/json 的编解码器
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string { return CodecJSON }
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// gobCodec 基于 encoding/gob 的编解码器，可保留数值等具体类型
+// 通过 interface{} 传输的自定义类型需先调用 gob.Register 注册
+type gobCodec struct{}
+
+func (gobCodec) Name() string { return CodecGob }
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// assignResult 将响应数据写入resp，类型可直接赋值时不经过序列化
+func assignResult(data interface{}, resp interface{}) error {
+	if resp == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(resp)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() && data != nil {
+		dv := reflect.ValueOf(data)
+		if dv.Type().AssignableTo(rv.Elem().Type()) {
+			rv.Elem().Set(dv)
+			return nil
+		}
+	}
+
+	// 类型不一致时退化为 JSON 转换
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal response failed: %w", err)
+	}
+	return json.Unmarshal(raw, resp)
+}
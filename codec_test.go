@@ -0,0 +1,75 @@
+package actor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateCodec(t *testing.T) {
+	tests := []struct {
+		name     string
+		offered  []string
+		accepted []string
+		want     string
+		wantErr  bool
+	}{
+		{"client preference", []string{CodecGob, CodecJSON}, nil, CodecGob, false},
+		{"restricted by server", []string{CodecGob, CodecJSON}, []string{CodecJSON}, CodecJSON, false},
+		{"skips unregistered", []string{CodecMsgPack, CodecJSON}, nil, CodecJSON, false},
+		{"no common codec", []string{CodecGob}, []string{CodecJSON}, "", true},
+		{"nothing offered", nil, nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := negotiateCodec(tt.offered, tt.accepted)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("negotiated %s, want error", c.Name())
+				}
+				return
+			}
+			if err != nil || c.Name() != tt.want {
+				t.Fatalf("got %v %v, want %s", c, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	values := []interface{}{
+		"text",
+		map[string]interface{}{"k": "v"},
+		[]interface{}{"a", "b"},
+	}
+	for _, name := range []string{CodecJSON, CodecGob} {
+		c, _ := GetCodec(name)
+		for _, v := range values {
+			typ, data, err := encodePayload(c, v)
+			if err != nil {
+				t.Fatalf("%s: encode %v: %v", name, v, err)
+			}
+			got, err := decodePayload(c, typ, data)
+			if err != nil || !reflect.DeepEqual(got, v) {
+				t.Fatalf("%s: round trip %#v = %#v, %v", name, v, got, err)
+			}
+		}
+	}
+}
+
+func TestRemoteCodecs(t *testing.T) {
+	sys := NewActorSystem()
+	if _, err := sys.RegisterActor("echo", echo); err != nil {
+		t.Fatal(err)
+	}
+	_, addr := startServer(t, sys, WithServerCodecs(CodecGob))
+	ctx := testContext(t)
+
+	var out string
+	if err := dial(t, "echo", addr, WithCodecs(CodecJSON, CodecGob)).Request(ctx, "hi", &out); err != nil || out != "hi" {
+		t.Fatal(out, err)
+	}
+	if _, err := NewRemoteActorRef("echo", addr, WithCodecs(CodecJSON)); err == nil || !strings.Contains(err.Error(), "no common codec") {
+		t.Fatalf("connected without a common codec: %v", err)
+	}
+}
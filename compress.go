@@ -0,0 +1,138 @@
+package actor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// 压缩算法名称
+const (
+	CompressionGzip   = "gzip"
+	CompressionZstd   = "zstd"   // 预留，需通过 RegisterCompressor 注册实现
+	CompressionSnappy = "snappy" // 预留，需通过 RegisterCompressor 注册实现
+
+	// DefaultCompressionThreshold 默认压缩阈值，小于该长度的帧不压缩
+	DefaultCompressionThreshold = 1024
+)
+
+// flagCompressed 帧负载已压缩
+const flagCompressed uint8 = 1 << 0
+
+// Compressor 定义帧负载的压缩算法
+type Compressor interface {
+	// Name 算法名称，用于握手协商
+	Name() string
+
+	Compress(src []byte) ([]byte, error)
+	Decompress(src []byte) ([]byte, error)
+}
+
+var (
+	compressorsMu sync.RWMutex
+	compressors   = map[string]Compressor{
+		CompressionGzip: gzipCompressor{},
+	}
+)
+
+// RegisterCompressor 注册一个压缩算法，同名算法会被覆盖
+func RegisterCompressor(c Compressor) {
+	compressorsMu.Lock()
+	defer compressorsMu.Unlock()
+	compressors[c.Name()] = c
+}
+
+// GetCompressor 按名称获取压缩算法
+func GetCompressor(name string) (Compressor, bool) {
+	compressorsMu.RLock()
+	defer compressorsMu.RUnlock()
+	c, ok := compressors[name]
+	return c, ok
+}
+
+// negotiateCompressor 按客户端偏好选择压缩算法，没有共同算法时不压缩
+func negotiateCompressor(offered []string, accepted []string) Compressor {
+	for _, name := range offered {
+		if !containsString(accepted, name) {
+			continue
+		}
+		if c, ok := GetCompressor(name); ok {
+			return c
+		}
+	}
+	return nil
+}
+
+// gzipCompressor 基于 compress/gzip 的压缩算法
+type gzipCompressor struct{}
+
+func (gzipCompressor) Name() string { return CompressionGzip }
+
+func (gzipCompressor) Compress(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCompressor) Decompress(src []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return readLimited(r, maxFrameSize)
+}
+
+// readLimited 读取全部数据，超过limit字节时返回错误而不是截断
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("decompressed frame too large: exceeds %d bytes", limit)
+	}
+	return data, nil
+}
+
+// LinkStats 连接的流量统计，Raw为压缩前字节数，Wire为实际传输的负载字节数
+type LinkStats struct {
+	RawBytesSent      int64
+	WireBytesSent     int64
+	RawBytesReceived  int64
+	WireBytesReceived int64
+}
+
+// CompressionRatio 返回发送方向传输字节数与原始字节数之比，未发送数据时为1
+func (s LinkStats) CompressionRatio() float64 {
+	if s.RawBytesSent == 0 {
+		return 1
+	}
+	return float64(s.WireBytesSent) / float64(s.RawBytesSent)
+}
+
+// linkStats 可并发更新的流量统计
+type linkStats struct {
+	rawSent      atomic.Int64
+	wireSent     atomic.Int64
+	rawReceived  atomic.Int64
+	wireReceived atomic.Int64
+}
+
+func (s *linkStats) snapshot() LinkStats {
+	return LinkStats{
+		RawBytesSent:      s.rawSent.Load(),
+		WireBytesSent:     s.wireSent.Load(),
+		RawBytesReceived:  s.rawReceived.Load(),
+		WireBytesReceived: s.wireReceived.Load(),
+	}
+}
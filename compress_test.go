@@ -0,0 +1,102 @@
+package actor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+)
+
+func TestReadLimited(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		limit   int64
+		wantErr bool
+	}{
+		{"below limit", 9, 10, false},
+		{"at limit", 10, 10, false},
+		{"above limit", 11, 10, true},
+		{"empty", 0, 10, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := readLimited(bytes.NewReader(make([]byte, tt.size)), tt.limit)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "too large") {
+					t.Fatalf("got %v, want too large error", err)
+				}
+				return
+			}
+			if err != nil || len(data) != tt.size {
+				t.Fatalf("got %d bytes, %v", len(data), err)
+			}
+		})
+	}
+}
+
+func TestGzipRejectsOversizedFrame(t *testing.T) {
+	if testing.Short() {
+		t.Skip("decompresses a 64MB frame")
+	}
+	var buf bytes.Buffer
+	w, _ := gzip.NewWriterLevel(&buf, gzip.BestSpeed)
+	chunk := make([]byte, 1<<20)
+	for i := 0; i <= maxFrameSize/len(chunk); i++ {
+		_, _ = w.Write(chunk)
+	}
+	_ = w.Close()
+	if _, err := (gzipCompressor{}).Decompress(buf.Bytes()); err == nil {
+		t.Fatal("expected decompressed frame too large error")
+	}
+}
+
+func TestNegotiateCompressor(t *testing.T) {
+	tests := []struct {
+		offered, accepted []string
+		want              string
+	}{
+		{[]string{CompressionGzip}, []string{CompressionGzip}, CompressionGzip},
+		{[]string{CompressionZstd, CompressionGzip}, []string{CompressionGzip}, CompressionGzip},
+		{[]string{CompressionGzip}, nil, ""},
+		{nil, []string{CompressionGzip}, ""},
+		{[]string{CompressionZstd}, []string{CompressionZstd}, ""}, // 未注册实现
+	}
+	for _, tt := range tests {
+		c := negotiateCompressor(tt.offered, tt.accepted)
+		got := ""
+		if c != nil {
+			got = c.Name()
+		}
+		if got != tt.want {
+			t.Errorf("negotiateCompressor(%v, %v) = %q, want %q", tt.offered, tt.accepted, got, tt.want)
+		}
+	}
+}
+
+func TestCompressionRoundTrip(t *testing.T) {
+	sys := NewActorSystem()
+	_, _ = sys.RegisterActor("echo", echo)
+	srv, addr := startServer(t, sys)
+	ref := dial(t, "echo", addr, WithCompression(100, CompressionGzip))
+
+	big := strings.Repeat("hello world ", 1000)
+	var out string
+	if err := ref.Request(testContext(t), big, &out); err != nil || out != big {
+		t.Fatalf("round trip failed: %v", err)
+	}
+	if r := ref.(RemoteActorRef).Stats().CompressionRatio(); r > 0.5 {
+		t.Fatalf("client compression ratio %.2f", r)
+	}
+	if r := srv.Stats().CompressionRatio(); r > 0.5 {
+		t.Fatalf("server compression ratio %.2f", r)
+	}
+
+	plain := dial(t, "echo", addr)
+	if err := plain.Request(testContext(t), big, &out); err != nil || out != big {
+		t.Fatalf("uncompressed round trip failed: %v", err)
+	}
+	if r := plain.(RemoteActorRef).Stats().CompressionRatio(); r != 1 {
+		t.Fatalf("uncompressed link ratio %.2f", r)
+	}
+}
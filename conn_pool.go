@@ -0,0 +1,65 @@
+package actor
+
+import "sync"
+
+// connPool 按地址复用到其他节点的连接，同一连接可发往对端的任意actor
+type connPool struct {
+	mu    sync.Mutex
+	conns map[string]*remoteActorRef
+	opts  []RemoteOption
+}
+
+func newConnPool(opts []RemoteOption) *connPool {
+	return &connPool{
+		conns: make(map[string]*remoteActorRef),
+		opts:  opts,
+	}
+}
+
+// ref 返回address上id的引用，连接已关闭时重新建立
+func (p *connPool) ref(address string, id string) (ActorRef, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	conn, ok := p.conns[address]
+	if ok {
+		select {
+		case <-conn.closed:
+			delete(p.conns, address)
+			ok = false
+		default:
+		}
+	}
+	if !ok {
+		ref, err := NewRemoteActorRef("", address, p.opts...)
+		if err != nil {
+			return nil, err
+		}
+		conn = ref.(*remoteActorRef)
+		p.conns[address] = conn
+	}
+	return &selectedRemoteRef{conn: conn, id: id}, nil
+}
+
+// drop 关闭出错的ref所用的连接，池中已换成新连接时不受影响
+func (p *connPool) drop(address string, failed ActorRef) {
+	ref, ok := failed.(*selectedRemoteRef)
+	if !ok {
+		return
+	}
+	p.mu.Lock()
+	if p.conns[address] == ref.conn {
+		delete(p.conns, address)
+	}
+	p.mu.Unlock()
+	_ = ref.conn.Close()
+}
+
+// close 关闭所有连接
+func (p *connPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for addr, conn := range p.conns {
+		_ = conn.Close()
+		delete(p.conns, addr)
+	}
+}
@@ -0,0 +1,41 @@
+package actor
+
+import "testing"
+
+func TestConnPoolDropKeepsNewerConnection(t *testing.T) {
+	sys := NewActorSystem()
+	if _, err := sys.RegisterActor("echo", echo); err != nil {
+		t.Fatal(err)
+	}
+	_, addr := startServer(t, sys)
+	ctx := testContext(t)
+	pool := newConnPool(nil)
+	defer pool.close()
+
+	stale, err := pool.ref(addr, "echo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.drop(addr, stale)
+	fresh, err := pool.ref(addr, "echo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fresh.(*selectedRemoteRef).conn == stale.(*selectedRemoteRef).conn {
+		t.Fatal("dropped connection was reused")
+	}
+
+	// 迟到的失败报告不能关闭已替换的新连接
+	pool.drop(addr, stale)
+	var out string
+	if err := fresh.Request(ctx, "hi", &out); err != nil || out != "hi" {
+		t.Fatal(out, err)
+	}
+	again, err := pool.ref(addr, "echo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.(*selectedRemoteRef).conn != fresh.(*selectedRemoteRef).conn {
+		t.Fatal("stale drop evicted the current connection")
+	}
+}
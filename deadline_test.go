@@ -0,0 +1,105 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRemainingTimeout(t *testing.T) {
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	soon, cancel2 := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel2()
+
+	if got := remainingTimeout(context.Background()); got != 0 {
+		t.Fatalf("no deadline: got %d", got)
+	}
+	if got := remainingTimeout(expired); got != 1 {
+		t.Fatalf("expired: got %d, want 1", got)
+	}
+	if got := time.Duration(remainingTimeout(soon)); got <= 59*time.Second || got > time.Minute {
+		t.Fatalf("one minute: got %v", got)
+	}
+}
+
+func TestDeadlinePropagation(t *testing.T) {
+	sys := NewActorSystem()
+	_, _ = sys.RegisterContextActor("remaining", func(ctx context.Context, _ interface{}) (interface{}, error) {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			return int64(-1), nil
+		}
+		return int64(time.Until(deadline)), nil
+	})
+	_, addr := startServer(t, sys)
+	ref := dial(t, "remaining", addr)
+
+	var got int64
+	if err := ref.Request(context.Background(), nil, &got); err != nil || got != -1 {
+		t.Fatalf("no deadline: got %v, %v", time.Duration(got), err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	if err := ref.Request(ctx, nil, &got); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Duration(got); d <= 300*time.Millisecond || d > 500*time.Millisecond {
+		t.Fatalf("server saw %v remaining, want close to 500ms", d)
+	}
+}
+
+func TestCancellationPropagation(t *testing.T) {
+	sys := NewActorSystem()
+	seen := make(chan error, 1)
+	_, _ = sys.RegisterContextActor("wait", func(ctx context.Context, _ interface{}) (interface{}, error) {
+		select {
+		case <-ctx.Done():
+			seen <- ctx.Err()
+		case <-time.After(5 * time.Second):
+			seen <- nil
+		}
+		return nil, nil
+	})
+	_, addr := startServer(t, sys)
+	ref := dial(t, "wait", addr)
+
+	tests := []struct {
+		name    string
+		ctx     func() (context.Context, context.CancelFunc)
+		want    error
+		handler []error // 服务端可能看到的错误
+	}{
+		// 服务端的截止时间与客户端的取消帧几乎同时到达，两种错误都可能先发生
+		{"timeout", func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.Background(), 100*time.Millisecond)
+		}, context.DeadlineExceeded, []error{context.DeadlineExceeded, context.Canceled}},
+		{"cancel", func() (context.Context, context.CancelFunc) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(100*time.Millisecond, cancel)
+			return ctx, cancel
+		}, context.Canceled, []error{context.Canceled}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := tt.ctx()
+			defer cancel()
+			if err := ref.Request(ctx, nil, nil); !errors.Is(err, tt.want) {
+				t.Fatalf("client got %v, want %v", err, tt.want)
+			}
+			select {
+			case err := <-seen:
+				ok := false
+				for _, want := range tt.handler {
+					ok = ok || errors.Is(err, want)
+				}
+				if !ok {
+					t.Fatalf("handler saw %v, want one of %v", err, tt.handler)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("handler was not cancelled")
+			}
+		})
+	}
+}
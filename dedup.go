@@ -0,0 +1,93 @@
+package actor
+
+import (
+	"sort"
+	"sync"
+)
+
+// deduplicator 按(发送方, 序号)识别重复投递
+type deduplicator struct {
+	window  uint64
+	mu      sync.Mutex
+	senders map[string]*seqWindow
+}
+
+// seqWindow 单个发送方的投递记录
+// delivered及以下的序号均已投递；更高的序号记录在seen中，值表示是否已投递完成，
+// 只有连续投递完成的序号才会推进delivered，未投递或投递失败的序号不会被当作重复
+type seqWindow struct {
+	delivered uint64
+	seen      map[uint64]bool
+}
+
+func newDeduplicator(window int) *deduplicator {
+	return &deduplicator{
+		window:  uint64(window),
+		senders: make(map[string]*seqWindow),
+	}
+}
+
+// mark 登记一次投递，重复时返回false，投递成功后需调用commit，失败时调用unmark
+func (d *deduplicator) mark(sender string, seq uint64) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	w, ok := d.senders[sender]
+	if !ok {
+		w = &seqWindow{seen: make(map[uint64]bool)}
+		d.senders[sender] = w
+	}
+	if seq <= w.delivered {
+		return false
+	}
+	if _, dup := w.seen[seq]; dup {
+		return false
+	}
+	w.seen[seq] = false
+	return true
+}
+
+// commit 确认序号已投递，推进连续投递的水位
+func (d *deduplicator) commit(sender string, seq uint64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	w, ok := d.senders[sender]
+	if !ok {
+		return
+	}
+	if _, ok := w.seen[seq]; !ok {
+		return
+	}
+	w.seen[seq] = true
+	for w.seen[w.delivered+1] {
+		delete(w.seen, w.delivered+1)
+		w.delivered++
+	}
+	if uint64(len(w.seen)) > d.window {
+		w.evict(len(w.seen) - int(d.window))
+	}
+}
+
+// evict 序号长期不连续时遗忘最早的n个已投递序号以限制内存，
+// 遗忘的序号再次到达时会被重复投递，但不会丢失
+func (w *seqWindow) evict(n int) {
+	done := make([]uint64, 0, len(w.seen))
+	for s, ok := range w.seen {
+		if ok {
+			done = append(done, s)
+		}
+	}
+	sort.Slice(done, func(i, j int) bool { return done[i] < done[j] })
+	for i := 0; i < n && i < len(done); i++ {
+		delete(w.seen, done[i])
+	}
+}
+
+// unmark 投递失败时撤销登记，使重投的消息可以再次投递
+func (d *deduplicator) unmark(sender string, seq uint64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if w, ok := d.senders[sender]; ok && !w.seen[seq] {
+		delete(w.seen, seq)
+	}
+}
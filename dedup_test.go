@@ -0,0 +1,131 @@
+package actor
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDeduplicator(t *testing.T) {
+	type step struct {
+		op   string // mark | commit | unmark
+		seq  uint64
+		want bool // mark的期望结果
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"first delivery", []step{{"mark", 1, true}, {"commit", 1, false}, {"mark", 1, false}}},
+		{"in progress is duplicate", []step{{"mark", 1, true}, {"mark", 1, false}}},
+		{"nack allows redelivery", []step{{"mark", 1, true}, {"unmark", 1, false}, {"mark", 1, true}}},
+		{"out of order", []step{
+			{"mark", 2, true}, {"commit", 2, false},
+			{"mark", 1, true}, {"commit", 1, false},
+			{"mark", 1, false}, {"mark", 2, false}, {"mark", 3, true},
+		}},
+		{"nacked seq below window is not a duplicate", []step{
+			{"mark", 1, true}, {"unmark", 1, false},
+			{"mark", 2, true}, {"commit", 2, false},
+			{"mark", 3, true}, {"commit", 3, false},
+			{"mark", 4, true}, {"commit", 4, false},
+			{"mark", 1, true}, {"commit", 1, false},
+			{"mark", 1, false}, {"mark", 4, false},
+		}},
+		{"unmark after commit is ignored", []step{{"mark", 1, true}, {"commit", 1, false}, {"unmark", 1, false}, {"mark", 1, false}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newDeduplicator(2)
+			for i, s := range tt.steps {
+				switch s.op {
+				case "mark":
+					if got := d.mark("s", s.seq); got != s.want {
+						t.Fatalf("step %d: mark(%d) = %v, want %v", i, s.seq, got, s.want)
+					}
+				case "commit":
+					d.commit("s", s.seq)
+				case "unmark":
+					d.unmark("s", s.seq)
+				}
+			}
+		})
+	}
+}
+
+func TestDeduplicatorEvictsOnlyDelivered(t *testing.T) {
+	d := newDeduplicator(2)
+	for seq := uint64(2); seq <= 10; seq++ {
+		d.mark("s", seq)
+		d.commit("s", seq)
+	}
+	if w := d.senders["s"]; len(w.seen) > 2 {
+		t.Fatalf("seen grew to %d entries", len(w.seen))
+	}
+	if !d.mark("s", 1) {
+		t.Fatal("undelivered seq 1 treated as duplicate")
+	}
+}
+
+func TestAtLeastOnceRedeliversNackedMessage(t *testing.T) {
+	sys := NewActorSystem()
+	blocked, release := make(chan struct{}), make(chan struct{})
+	var mu sync.Mutex
+	got := make(map[int]int)
+	_, _ = sys.RegisterActor("sink", func(msg interface{}) (interface{}, error) {
+		if msg == "block" {
+			blocked <- struct{}{}
+			<-release
+			return nil, nil
+		}
+		n, ok := msg.(float64)
+		if !ok {
+			return nil, nil
+		}
+		mu.Lock()
+		got[int(n)]++
+		mu.Unlock()
+		return nil, nil
+	})
+	_, addr := startServer(t, sys, WithDeduplication(2))
+
+	// 阻塞actor并占满邮箱，使第一条消息因邮箱已满被拒绝
+	local, _ := sys.actorFor("sink")
+	_ = local.Send(Message{Payload: "block"})
+	<-blocked
+	for local.Send(Message{Payload: "fill"}) == nil {
+	}
+	d, err := NewAtLeastOnceDelivery(func() (ActorRef, error) {
+		return NewRemoteActorRef("sink", addr)
+	}, AtLeastOnceConfig{SenderID: "s1", RedeliverAfter: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if _, err := d.Deliver(1); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "first delivery rejected", func() bool {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		_, failed := d.attempts[1]
+		return failed
+	})
+	close(release)
+
+	for i := 2; i <= 5; i++ {
+		if _, err := d.Deliver(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	eventually(t, "all confirmed", func() bool {
+		return d.Unconfirmed() == 0
+	})
+	mu.Lock()
+	defer mu.Unlock()
+	for i := 1; i <= 5; i++ {
+		if got[i] != 1 {
+			t.Fatalf("message %d delivered %d times: %v", i, got[i], got)
+		}
+	}
+}
@@ -0,0 +1,411 @@
+package actor
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Delivery 一条等待确认的消息，负载以JSON编码保存
+// 需要在重启后保持具体类型的负载应通过 RegisterMessageType 注册
+type Delivery struct {
+	Seq         uint64 `json:"seq"`
+	PayloadType string `json:"payload_type,omitempty"`
+	Payload     []byte `json:"payload,omitempty"`
+}
+
+// DeliveryStore 保存未确认的消息与已分配的最大序号
+type DeliveryStore interface {
+	Save(d Delivery) error
+	Confirm(seq uint64) error
+	Unconfirmed() ([]Delivery, error)
+	LastSeq() (uint64, error)
+}
+
+// MemoryDeliveryStore 基于内存的消息存储，进程退出后丢失
+type MemoryDeliveryStore struct {
+	mu      sync.Mutex
+	pending map[uint64]Delivery
+	lastSeq uint64
+}
+
+// NewMemoryDeliveryStore 创建内存消息存储
+func NewMemoryDeliveryStore() *MemoryDeliveryStore {
+	return &MemoryDeliveryStore{pending: make(map[uint64]Delivery)}
+}
+
+func (s *MemoryDeliveryStore) Save(d Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending[d.Seq] = d
+	if d.Seq > s.lastSeq {
+		s.lastSeq = d.Seq
+	}
+	return nil
+}
+
+func (s *MemoryDeliveryStore) Confirm(seq uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, seq)
+	return nil
+}
+
+func (s *MemoryDeliveryStore) Unconfirmed() ([]Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sortedDeliveries(s.pending), nil
+}
+
+func (s *MemoryDeliveryStore) LastSeq() (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastSeq, nil
+}
+
+func sortedDeliveries(m map[uint64]Delivery) []Delivery {
+	list := make([]Delivery, 0, len(m))
+	for _, d := range m {
+		list = append(list, d)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Seq < list[j].Seq })
+	return list
+}
+
+// fileRecord 文件存储的日志记录
+type fileRecord struct {
+	Op       string    `json:"op"` // save | confirm | seq
+	Seq      uint64    `json:"seq,omitempty"`
+	Delivery *Delivery `json:"delivery,omitempty"`
+}
+
+// FileDeliveryStore 基于追加日志文件的消息存储，打开时重放并压缩日志
+type FileDeliveryStore struct {
+	mem  *MemoryDeliveryStore
+	mu   sync.Mutex
+	file *os.File
+}
+
+// OpenFileDeliveryStore 打开或创建文件消息存储
+func OpenFileDeliveryStore(path string) (*FileDeliveryStore, error) {
+	mem := NewMemoryDeliveryStore()
+	if f, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), maxFrameSize)
+		for scanner.Scan() {
+			var rec fileRecord
+			if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+				break // 忽略崩溃时写入不完整的尾部记录
+			}
+			switch rec.Op {
+			case "save":
+				if rec.Delivery != nil {
+					mem.Save(*rec.Delivery)
+				}
+			case "confirm":
+				mem.Confirm(rec.Seq)
+			case "seq":
+				if rec.Seq > mem.lastSeq {
+					mem.lastSeq = rec.Seq
+				}
+			}
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	// 压缩: 只保留最大序号与未确认的消息
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s := &FileDeliveryStore{mem: mem, file: f}
+	err = s.append(fileRecord{Op: "seq", Seq: mem.lastSeq})
+	for _, d := range sortedDeliveries(mem.pending) {
+		if err != nil {
+			break
+		}
+		d := d
+		err = s.append(fileRecord{Op: "save", Delivery: &d})
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileDeliveryStore) append(rec fileRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	_, err = s.file.Write(append(data, '\n'))
+	return err
+}
+
+func (s *FileDeliveryStore) Save(d Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.append(fileRecord{Op: "save", Delivery: &d}); err != nil {
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+	return s.mem.Save(d)
+}
+
+func (s *FileDeliveryStore) Confirm(seq uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.append(fileRecord{Op: "confirm", Seq: seq}); err != nil {
+		return err
+	}
+	return s.mem.Confirm(seq)
+}
+
+func (s *FileDeliveryStore) Unconfirmed() ([]Delivery, error) {
+	return s.mem.Unconfirmed()
+}
+
+func (s *FileDeliveryStore) LastSeq() (uint64, error) {
+	return s.mem.LastSeq()
+}
+
+// Close 关闭日志文件
+func (s *FileDeliveryStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// AtLeastOnceConfig 至少一次投递配置
+type AtLeastOnceConfig struct {
+	// SenderID 发送方标识，服务端按(SenderID, 序号)去重，重启后应保持不变
+	SenderID string
+
+	Store          DeliveryStore // 默认使用内存存储
+	RedeliverAfter time.Duration // 未确认消息的重投间隔，默认5s
+	AckTimeout     time.Duration // 单次投递等待确认的超时，默认与RedeliverAfter相同
+}
+
+// AtLeastOnceDelivery 在远程引用之上提供至少一次投递
+// 消息先写入存储再发送，收到服务端确认后才从存储中删除，连接断开时自动重连并重投
+type AtLeastOnceDelivery struct {
+	dial func() (ActorRef, error)
+	cfg  AtLeastOnceConfig
+
+	mu       sync.Mutex
+	ref      ActorRef
+	nextSeq  uint64
+	attempts map[uint64]time.Time // 序号 -> 下次投递时间
+
+	kick chan struct{}
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewAtLeastOnceDelivery 创建至少一次投递助手，dial用于建立或重建到目标actor的引用
+// 服务端需通过 WithDeduplication 开启去重
+func NewAtLeastOnceDelivery(dial func() (ActorRef, error), cfg AtLeastOnceConfig) (*AtLeastOnceDelivery, error) {
+	if cfg.SenderID == "" {
+		cfg.SenderID = newNodeID()
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryDeliveryStore()
+	}
+	if cfg.RedeliverAfter <= 0 {
+		cfg.RedeliverAfter = time.Second * 5
+	}
+	if cfg.AckTimeout <= 0 {
+		cfg.AckTimeout = cfg.RedeliverAfter
+	}
+	last, err := cfg.Store.LastSeq()
+	if err != nil {
+		return nil, err
+	}
+
+	d := &AtLeastOnceDelivery{
+		dial:     dial,
+		cfg:      cfg,
+		nextSeq:  last + 1,
+		attempts: make(map[uint64]time.Time),
+		kick:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	d.wg.Add(1)
+	go d.loop()
+	d.trigger() // 立即重投上次未确认的消息
+	return d, nil
+}
+
+// Deliver 持久化消息并异步投递，返回分配的序号
+func (d *AtLeastOnceDelivery) Deliver(msg interface{}) (uint64, error) {
+	name, data, err := encodePayload(jsonCodec{}, msg)
+	if err != nil {
+		return 0, fmt.Errorf("encode message failed: %w", err)
+	}
+
+	d.mu.Lock()
+	seq := d.nextSeq
+	d.nextSeq++
+	d.mu.Unlock()
+
+	if err := d.cfg.Store.Save(Delivery{Seq: seq, PayloadType: name, Payload: data}); err != nil {
+		return 0, err
+	}
+	d.trigger()
+	return seq, nil
+}
+
+// Unconfirmed 返回尚未确认的消息数量
+func (d *AtLeastOnceDelivery) Unconfirmed() int {
+	list, _ := d.cfg.Store.Unconfirmed()
+	return len(list)
+}
+
+// Close 停止重投，未确认的消息保留在存储中
+func (d *AtLeastOnceDelivery) Close() {
+	select {
+	case <-d.done:
+		return
+	default:
+	}
+	close(d.done)
+	d.wg.Wait()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if c, ok := d.ref.(interface{ Close() error }); ok {
+		c.Close()
+	}
+	d.ref = nil
+}
+
+func (d *AtLeastOnceDelivery) trigger() {
+	select {
+	case d.kick <- struct{}{}:
+	default:
+	}
+}
+
+func (d *AtLeastOnceDelivery) loop() {
+	defer d.wg.Done()
+	ticker := time.NewTicker(d.cfg.RedeliverAfter / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.done:
+			return
+		case <-d.kick:
+		case <-ticker.C:
+		}
+		d.redeliver()
+	}
+}
+
+// redeliver 投递所有到期的未确认消息，连接失败时本轮停止等待下次重连
+func (d *AtLeastOnceDelivery) redeliver() {
+	list, err := d.cfg.Store.Unconfirmed()
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	for _, dl := range list {
+		select {
+		case <-d.done:
+			return
+		default:
+		}
+
+		d.mu.Lock()
+		due := d.attempts[dl.Seq]
+		d.mu.Unlock()
+		if now.Before(due) {
+			continue
+		}
+
+		err := d.send(dl)
+		d.mu.Lock()
+		if err == nil {
+			delete(d.attempts, dl.Seq)
+		} else {
+			d.attempts[dl.Seq] = time.Now().Add(d.cfg.RedeliverAfter)
+		}
+		d.mu.Unlock()
+
+		if err == nil {
+			d.cfg.Store.Confirm(dl.Seq)
+			continue
+		}
+		if isLinkError(err) {
+			d.resetRef()
+			return
+		}
+	}
+}
+
+func (d *AtLeastOnceDelivery) send(dl Delivery) error {
+	ref, err := d.currentRef()
+	if err != nil {
+		return err
+	}
+	msg, err := decodePayload(jsonCodec{}, dl.PayloadType, dl.Payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.cfg.AckTimeout)
+	defer cancel()
+	if r, ok := ref.(*remoteActorRef); ok {
+		return r.tellSequenced(ctx, msg, d.cfg.SenderID, dl.Seq)
+	}
+	return ref.TellWithAck(ctx, msg)
+}
+
+func (d *AtLeastOnceDelivery) currentRef() (ActorRef, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.ref == nil {
+		ref, err := d.dial()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrConnectionClosed, err)
+		}
+		d.ref = ref
+	}
+	return d.ref, nil
+}
+
+func (d *AtLeastOnceDelivery) resetRef() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if c, ok := d.ref.(interface{ Close() error }); ok {
+		c.Close()
+	}
+	d.ref = nil
+}
+
+// isLinkError 判断是否为需要重建连接的错误
+func isLinkError(err error) bool {
+	return errors.Is(err, ErrConnectionClosed) ||
+		errors.Is(err, ErrPeerDead) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
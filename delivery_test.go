@@ -0,0 +1,85 @@
+package actor
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTellWithAck(t *testing.T) {
+	sys := NewActorSystem()
+	received := make(chan interface{}, 1)
+	if _, err := sys.RegisterActor("sink", func(msg interface{}) (interface{}, error) {
+		received <- msg
+		return nil, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	blocked := make(chan struct{})
+	started := make(chan struct{}, 1)
+	if _, err := sys.RegisterActor("full", func(msg interface{}) (interface{}, error) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-blocked
+		return nil, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	stopping, err := sys.RegisterActor("stopping", func(msg interface{}) (interface{}, error) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-blocked
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer close(blocked)
+	_, addr := startServer(t, sys)
+	ctx := testContext(t)
+
+	// stopping正在停止：处理中的消息完成前仍留在系统中，但拒绝新消息
+	if err := stopping.Tell(0); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	go stopping.(*localActorRef).actor.Stop()
+	eventually(t, "actor stopping", stopping.(*localActorRef).actor.IsStopped)
+
+	// 占满full的邮箱：一条消息在处理中，其余填满邮箱
+	full := dial(t, "full", addr)
+	if err := full.TellWithAck(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	for i := 0; i < 100; i++ {
+		if err := full.TellWithAck(ctx, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		err    error
+	}{
+		{"delivered", "sink", nil},
+		{"missing actor", "missing", ErrActorNotFound},
+		{"mailbox full", "full", ErrMailboxFull},
+		{"actor stopped", "stopping", ErrActorStopped},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := dial(t, tt.target, addr).TellWithAck(ctx, "hi")
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+	if msg := <-received; msg != "hi" {
+		t.Fatalf("received %v", msg)
+	}
+}
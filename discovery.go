@@ -0,0 +1,155 @@
+package actor
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ActorInfo 已注册actor的描述
+type ActorInfo struct {
+	ID              string `json:"id"`
+	Kind            string `json:"kind,omitempty"` // 远程创建时的类型名称
+	MailboxDepth    int    `json:"mailbox_depth"`  // 邮箱中等待处理的消息数
+	MailboxCapacity int    `json:"mailbox_capacity"`
+}
+
+// ListOptions 列出actor时的过滤条件，各条件同时生效，均为空时返回全部actor
+type ListOptions struct {
+	ID      string `json:"id,omitempty"` // 精确匹配，用于检查单个actor是否存在
+	Prefix  string `json:"prefix,omitempty"`
+	Pattern string `json:"pattern,omitempty"` // path.Match 通配符，例如 "sessions/*"
+	Kind    string `json:"kind,omitempty"`
+}
+
+func init() {
+	RegisterMessageType[ListOptions]("actor.ListOptions")
+	RegisterMessageType[[]ActorInfo]("actor.ActorInfoList")
+}
+
+// validate 检查通配符是否合法
+func (o ListOptions) validate() error {
+	if o.Pattern == "" {
+		return nil
+	}
+	if _, err := path.Match(o.Pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", o.Pattern, err)
+	}
+	return nil
+}
+
+func (o ListOptions) match(id string, kind string) bool {
+	if o.ID != "" && o.ID != id {
+		return false
+	}
+	if o.Prefix != "" && !strings.HasPrefix(id, o.Prefix) {
+		return false
+	}
+	if o.Kind != "" && o.Kind != kind {
+		return false
+	}
+	if o.Pattern != "" {
+		ok, _ := path.Match(o.Pattern, id)
+		return ok
+	}
+	return true
+}
+
+// List 按过滤条件列出已注册的actor，结果按id排序
+// 指定ID且匹配激活前缀时，尚未激活的actor也视为存在，其邮箱字段为0
+func (s *ActorSystem) List(opts ListOptions) ([]ActorInfo, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	actors := s.actors
+	infos := make([]ActorInfo, 0)
+	if opts.ID != "" {
+		actors = make(map[string]*Actor, 1)
+		if a, ok := s.actors[opts.ID]; ok {
+			actors[opts.ID] = a
+		} else if act, ok := s.activatorFor(opts.ID); ok && opts.match(opts.ID, act.kind) {
+			infos = append(infos, ActorInfo{ID: opts.ID, Kind: act.kind})
+		}
+	}
+	for id, a := range actors {
+		if !opts.match(id, a.kind) {
+			continue
+		}
+		infos = append(infos, ActorInfo{
+			ID:              id,
+			Kind:            a.kind,
+			MailboxDepth:    len(a.mailbox),
+			MailboxCapacity: cap(a.mailbox),
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ID < infos[j].ID
+	})
+	return infos, nil
+}
+
+// list 列出调用方有权访问的actor
+func (s *ActorServer) list(p *Principal, opts ListOptions) ([]ActorInfo, error) {
+	infos, err := s.sys.List(opts)
+	if err != nil {
+		return nil, err
+	}
+	authz := s.opts.authorizer
+	if authz == nil {
+		return infos, nil
+	}
+	visible := infos[:0]
+	for _, info := range infos {
+		if authz.Authorize(p, info.ID) == nil {
+			visible = append(visible, info)
+		}
+	}
+	return visible, nil
+}
+
+// listActors 通过连接查询服务端的actor列表
+func (r *remoteActorRef) listActors(ctx context.Context, opts ListOptions) ([]ActorInfo, error) {
+	response, err := r.call(ctx, frameList, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+	var infos []ActorInfo
+	if err := assignResult(response.Data, &infos); err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
+
+// ListRemote 列出address上的服务端中调用方有权访问的actor
+func ListRemote(ctx context.Context, address string, opts ListOptions, ropts ...RemoteOption) ([]ActorInfo, error) {
+	ref, err := NewRemoteActorRef("", address, ropts...)
+	if err != nil {
+		return nil, err
+	}
+	defer ref.(*remoteActorRef).Close()
+	return ref.(*remoteActorRef).listActors(ctx, opts)
+}
+
+// ResolveRemote 返回address上已存在的actor的远程引用，actor不存在或无权访问时返回 ErrActorNotFound
+func ResolveRemote(ctx context.Context, address string, id string, ropts ...RemoteOption) (ActorRef, error) {
+	ref, err := NewRemoteActorRef(id, address, ropts...)
+	if err != nil {
+		return nil, err
+	}
+	r := ref.(*remoteActorRef)
+	infos, err := r.listActors(ctx, ListOptions{ID: id})
+	if err != nil {
+		_ = r.Close()
+		return nil, err
+	}
+	if len(infos) > 0 {
+		return ref, nil
+	}
+	_ = r.Close()
+	return nil, fmt.Errorf("%w: %s", ErrActorNotFound, id)
+}
@@ -0,0 +1,105 @@
+package actor
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestListOptionsMatch(t *testing.T) {
+	tests := []struct {
+		name string
+		opts ListOptions
+		id   string
+		kind string
+		want bool
+	}{
+		{"empty", ListOptions{}, "a", "", true},
+		{"id exact", ListOptions{ID: "user/1"}, "user/1", "", true},
+		{"id is not a prefix", ListOptions{ID: "user/1"}, "user/10", "", false},
+		{"prefix", ListOptions{Prefix: "user/"}, "user/10", "", true},
+		{"prefix mismatch", ListOptions{Prefix: "user/"}, "admin", "", false},
+		{"pattern", ListOptions{Pattern: "user/*"}, "user/1", "", true},
+		{"pattern depth", ListOptions{Pattern: "user/*"}, "user/1/x", "", false},
+		{"kind", ListOptions{Kind: "echo"}, "a", "echo", true},
+		{"kind mismatch", ListOptions{Kind: "echo"}, "a", "", false},
+		{"all conditions", ListOptions{ID: "user/1", Prefix: "user/", Kind: "echo"}, "user/1", "echo", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.match(tt.id, tt.kind); got != tt.want {
+				t.Fatalf("match(%q, %q) = %v, want %v", tt.id, tt.kind, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListRemote(t *testing.T) {
+	sys := NewActorSystem()
+	for _, id := range []string{"sessions/a", "sessions/b", "admin"} {
+		if _, err := sys.RegisterActor(id, echo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, addr := startServer(t, sys)
+	ctx := testContext(t)
+
+	for _, codec := range []string{"json", "gob"} {
+		infos, err := ListRemote(ctx, addr, ListOptions{Pattern: "sessions/*"}, WithCodecs(codec))
+		if err != nil || len(infos) != 2 || infos[0].ID != "sessions/a" || infos[0].MailboxCapacity != 100 {
+			t.Fatalf("%s: %v %+v", codec, err, infos)
+		}
+		infos, err = ListRemote(ctx, addr, ListOptions{ID: "admin"}, WithCodecs(codec))
+		if err != nil || len(infos) != 1 || infos[0].ID != "admin" {
+			t.Fatalf("%s: %v %+v", codec, err, infos)
+		}
+		if _, err := ListRemote(ctx, addr, ListOptions{Pattern: "["}, WithCodecs(codec)); err == nil {
+			t.Fatalf("%s: invalid pattern accepted", codec)
+		}
+	}
+}
+
+func TestResolveRemoteExactID(t *testing.T) {
+	sys := NewActorSystem()
+	// 大量共享前缀的id不应影响单个id的解析
+	for i := 0; i < 500; i++ {
+		if _, err := sys.RegisterActor("user/1/"+string(rune('a'+i%26))+string(rune('a'+i/26)), echo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := sys.RegisterActor("user/1", echo); err != nil {
+		t.Fatal(err)
+	}
+	acl := NewACL().Allow("alice", "user/*")
+	_, addr := startServer(t, sys,
+		WithAuthenticator(TokenAuthenticator{"alice": "secret"}),
+		WithAuthorizer(acl))
+	ctx := testContext(t)
+	creds := WithCredentials(TokenCredentials("alice", "secret"))
+
+	tests := []struct {
+		name string
+		id   string
+		err  error
+	}{
+		{"exact", "user/1", nil},
+		{"prefix only", "user/", ErrActorNotFound},
+		{"missing", "user/2", ErrActorNotFound},
+		{"denied", "user/1/aa", ErrActorNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, err := ResolveRemote(ctx, addr, tt.id, creds)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ResolveRemote(%q) = %v, want %v", tt.id, err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			defer ref.(*remoteActorRef).Close()
+			var out string
+			if err := ref.Request(ctx, "hi", &out); err != nil || out != "hi" {
+				t.Fatal(out, err)
+			}
+		})
+	}
+}
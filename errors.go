@@ -0,0 +1,156 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// 内置错误码
+const (
+	CodeUnknown          = "unknown"
+	CodeInternal         = "internal"
+	CodeInvalidMessage   = "invalid_message"
+	CodeActorNotFound    = "actor_not_found"
+	CodeMailboxFull      = "mailbox_full"
+	CodeActorStopped     = "actor_stopped"
+	CodeActorExists      = "actor_exists"
+	CodeKindNotFound     = "kind_not_found"
+	CodeUnauthenticated  = "unauthenticated"
+	CodePermissionDenied = "permission_denied"
+	CodePeerDead         = "peer_dead"
+	CodeServerBusy       = "server_busy"
+	CodeConnectionClosed = "connection_closed"
+	CodeBackpressure     = "backpressure"
+	CodeStreamClosed     = "stream_closed"
+	CodeCanceled         = "canceled"
+	CodeDeadlineExceeded = "deadline_exceeded"
+)
+
+// Error 跨进程传递的结构化错误，作为 Response 与远程响应的错误字段
+// 错误码对应已注册的错误值时，errors.Is 可以在本地和远程引用上匹配该错误值
+type Error struct {
+	Code      string            `json:"code"`
+	Message   string            `json:"message"`
+	Details   map[string]string `json:"details,omitempty"`
+	Retryable bool              `json:"retryable,omitempty"`
+
+	cause error // 本地产生的原始错误，不参与编码
+}
+
+// NewError 创建结构化错误，可重试标记取错误码注册时的设置
+func NewError(code, message string) *Error {
+	e := &Error{Code: code, Message: message}
+	if k, ok := lookupErrorCode(code); ok {
+		e.Retryable = k.retryable
+	}
+	return e
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap 返回原始错误，远程收到的错误返回错误码对应的错误值
+func (e *Error) Unwrap() error {
+	if e.cause != nil {
+		return e.cause
+	}
+	if k, ok := lookupErrorCode(e.Code); ok {
+		return k.err
+	}
+	return nil
+}
+
+// WithDetail 附加一项错误详情并返回e本身
+func (e *Error) WithDetail(key, value string) *Error {
+	if e.Details == nil {
+		e.Details = make(map[string]string)
+	}
+	e.Details[key] = value
+	return e
+}
+
+// IsRetryable 判断错误是否可以重试
+func IsRetryable(err error) bool {
+	if e := toError(err); e != nil {
+		return e.Retryable
+	}
+	return false
+}
+
+type errorCode struct {
+	code      string
+	err       error
+	retryable bool
+}
+
+var (
+	errorCodesMu sync.RWMutex
+	// 按顺序匹配，同时包装多个错误值时取靠前的错误码
+	errorCodes = []errorCode{
+		{CodeActorNotFound, ErrActorNotFound, false},
+		{CodeMailboxFull, ErrMailboxFull, true},
+		{CodeActorStopped, ErrActorStopped, false},
+		{CodeActorExists, ErrActorExists, false},
+		{CodeKindNotFound, ErrKindNotFound, false},
+		{CodeUnauthenticated, ErrUnauthenticated, false},
+		{CodePermissionDenied, ErrPermissionDenied, false},
+		{CodePeerDead, ErrPeerDead, true},
+		{CodeServerBusy, ErrServerBusy, true},
+		{CodeConnectionClosed, ErrConnectionClosed, true},
+		{CodeBackpressure, ErrBackpressure, true},
+		{CodeStreamClosed, ErrStreamClosed, false},
+		{CodeCanceled, context.Canceled, false},
+		{CodeDeadlineExceeded, context.DeadlineExceeded, true},
+	}
+)
+
+// RegisterErrorCode 为错误值注册错误码，使其在远程调用中可以通过 errors.Is 匹配，
+// 两端需注册相同的错误码
+func RegisterErrorCode(code string, err error, retryable bool) {
+	errorCodesMu.Lock()
+	defer errorCodesMu.Unlock()
+	for i := range errorCodes {
+		if errorCodes[i].code == code {
+			errorCodes[i] = errorCode{code, err, retryable}
+			return
+		}
+	}
+	errorCodes = append(errorCodes, errorCode{code, err, retryable})
+}
+
+func lookupErrorCode(code string) (errorCode, bool) {
+	errorCodesMu.RLock()
+	defer errorCodesMu.RUnlock()
+	for _, k := range errorCodes {
+		if k.code == code {
+			return k, true
+		}
+	}
+	return errorCode{}, false
+}
+
+// toError 将任意错误转换为结构化错误，nil 返回 nil
+func toError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		if e == err {
+			return e
+		}
+		// 外层包装补充的上下文保留在消息中
+		return &Error{Code: e.Code, Message: err.Error(), Details: e.Details, Retryable: e.Retryable, cause: err}
+	}
+
+	errorCodesMu.RLock()
+	defer errorCodesMu.RUnlock()
+	for _, k := range errorCodes {
+		if errors.Is(err, k.err) {
+			return &Error{Code: k.code, Message: err.Error(), Retryable: k.retryable, cause: err}
+		}
+	}
+	return &Error{Code: CodeUnknown, Message: err.Error(), cause: err}
+}
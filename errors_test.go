@@ -0,0 +1,63 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+var errTestQuota = errors.New("quota exceeded")
+
+func init() {
+	RegisterErrorCode("test_quota", errTestQuota, true)
+}
+
+func TestErrorPropagation(t *testing.T) {
+	sys := NewActorSystem()
+	local, _ := sys.RegisterContextActor("fail", func(ctx context.Context, msg interface{}) (interface{}, error) {
+		switch msg {
+		case "plain":
+			return nil, errors.New("100% broken %d")
+		case "detail":
+			return nil, NewError("invalid_argument", "bad name").WithDetail("field", "name")
+		case "quota":
+			return nil, errTestQuota
+		}
+		return nil, ErrMailboxFull
+	})
+	_, addr := startServer(t, sys)
+
+	tests := []struct {
+		msg   string
+		check func(err error) bool
+	}{
+		{"plain", func(err error) bool { return err != nil && err.Error() == "100% broken %d" }},
+		{"detail", func(err error) bool {
+			var e *Error
+			return errors.As(err, &e) && e.Code == "invalid_argument" && e.Details["field"] == "name" && !IsRetryable(err)
+		}},
+		{"quota", func(err error) bool { return errors.Is(err, errTestQuota) && IsRetryable(err) }},
+		{"builtin", func(err error) bool { return errors.Is(err, ErrMailboxFull) }},
+	}
+	for _, ref := range []struct {
+		name string
+		ref  ActorRef
+	}{
+		{"local", local},
+		{"json", dial(t, "fail", addr, WithCodecs(CodecJSON))},
+		{"gob", dial(t, "fail", addr, WithCodecs(CodecGob))},
+	} {
+		for _, tt := range tests {
+			t.Run(ref.name+"/"+tt.msg, func(t *testing.T) {
+				if err := ref.ref.Request(testContext(t), tt.msg, new(int)); !tt.check(err) {
+					t.Fatalf("unexpected error %#v", err)
+				}
+			})
+		}
+	}
+
+	err := dial(t, "missing", addr).Request(testContext(t), "x", new(int))
+	if !errors.Is(err, ErrActorNotFound) {
+		t.Fatalf("missing actor: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package actor
+
+import "sync"
+
+// EventStream 系统内的事件总线，例如集群成员变化事件 MemberEvent
+// 订阅者在发布者的goroutine中按发布顺序收到事件，处理函数应尽快返回
+type EventStream struct {
+	mu     sync.RWMutex
+	nextID int
+	subs   map[int]func(event interface{})
+}
+
+func newEventStream() *EventStream {
+	return &EventStream{subs: make(map[int]func(event interface{}))}
+}
+
+// Subscribe 订阅所有事件，返回取消订阅的函数
+func (e *EventStream) Subscribe(fn func(event interface{})) (unsubscribe func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	id := e.nextID
+	e.nextID++
+	e.subs[id] = fn
+	return func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		delete(e.subs, id)
+	}
+}
+
+// Publish 将事件发送给所有订阅者
+func (e *EventStream) Publish(event interface{}) {
+	e.mu.RLock()
+	subs := make([]func(interface{}), 0, len(e.subs))
+	for _, fn := range e.subs {
+		subs = append(subs, fn)
+	}
+	e.mu.RUnlock()
+	for _, fn := range subs {
+		fn(event)
+	}
+}
+
+// DeadLetter 无法投递而被丢弃的消息，发布在 ActorSystem.EventStream 上
+type DeadLetter struct {
+	Target  string // 目标actor id
+	Message interface{}
+	Err     error
+}
@@ -0,0 +1,230 @@
+package actor
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// FailureDetectorConfig phi-accrual失效检测配置，零值字段使用默认值
+type FailureDetectorConfig struct {
+	Threshold              float64       // phi超过该值时判定节点不可用，默认8
+	MaxSamples             int           // 保留的心跳间隔样本数，默认200
+	MinStdDeviation        time.Duration // 标准差下限，避免间隔过于稳定时误判，默认100ms
+	AcceptablePause        time.Duration // 可接受的额外停顿，例如GC暂停，默认0
+	FirstHeartbeatEstimate time.Duration // 收到足够样本前的心跳间隔估计，默认1s
+}
+
+func (c *FailureDetectorConfig) normalize() {
+	if c.Threshold <= 0 {
+		c.Threshold = 8
+	}
+	if c.MaxSamples <= 0 {
+		c.MaxSamples = 200
+	}
+	if c.MinStdDeviation <= 0 {
+		c.MinStdDeviation = 100 * time.Millisecond
+	}
+	if c.FirstHeartbeatEstimate <= 0 {
+		c.FirstHeartbeatEstimate = time.Second
+	}
+}
+
+// phiDetector 单个节点的心跳间隔统计
+type phiDetector struct {
+	intervals []float64 // 环形缓冲，单位毫秒
+	next      int
+	sum       float64
+	squares   float64
+	last      time.Time
+	available bool
+	source    uint64 // 当前向该节点提供心跳的连接，0表示通过 Heartbeat 直接提供
+}
+
+func newPhiDetector(cfg *FailureDetectorConfig) *phiDetector {
+	d := &phiDetector{
+		intervals: make([]float64, 0, cfg.MaxSamples),
+		available: true,
+	}
+	// 以估计值附近的两个样本作为初始分布
+	mean := float64(cfg.FirstHeartbeatEstimate.Milliseconds())
+	std := mean / 4
+	d.add(mean-std, cfg.MaxSamples)
+	d.add(mean+std, cfg.MaxSamples)
+	return d
+}
+
+func (d *phiDetector) add(interval float64, max int) {
+	if len(d.intervals) < max {
+		d.intervals = append(d.intervals, interval)
+	} else {
+		old := d.intervals[d.next]
+		d.sum -= old
+		d.squares -= old * old
+		d.intervals[d.next] = interval
+		d.next = (d.next + 1) % max
+	}
+	d.sum += interval
+	d.squares += interval * interval
+}
+
+func (d *phiDetector) phi(now time.Time, cfg *FailureDetectorConfig) float64 {
+	if d.last.IsZero() {
+		return 0
+	}
+	n := float64(len(d.intervals))
+	mean := d.sum / n
+	std := math.Sqrt(math.Max(d.squares/n-mean*mean, 0))
+	std = math.Max(std, float64(cfg.MinStdDeviation.Milliseconds()))
+	mean += float64(cfg.AcceptablePause.Milliseconds())
+
+	elapsed := float64(now.Sub(d.last).Milliseconds())
+	y := (elapsed - mean) / std
+	e := math.Exp(-y * (1.5976 + 0.070566*y*y))
+	if elapsed > mean {
+		return -math.Log10(e / (1 + e))
+	}
+	return -math.Log10(1 - 1/(1+e))
+}
+
+// FailureDetector 按地址维护phi-accrual失效检测器，由心跳到达时间驱动
+type FailureDetector struct {
+	cfg        FailureDetectorConfig
+	mu         sync.Mutex
+	nodes      map[string]*phiDetector
+	listeners  []func(addr string, available bool)
+	nextSource uint64
+}
+
+// NewFailureDetector 创建一个失效检测器，可在多个连接间共享
+func NewFailureDetector(cfg FailureDetectorConfig) *FailureDetector {
+	cfg.normalize()
+	return &FailureDetector{
+		cfg:   cfg,
+		nodes: make(map[string]*phiDetector),
+	}
+}
+
+// Heartbeat 记录一次来自addr的心跳
+func (f *FailureDetector) Heartbeat(addr string) {
+	now := time.Now()
+	f.mu.Lock()
+	d, ok := f.nodes[addr]
+	if !ok {
+		d = newPhiDetector(&f.cfg)
+		f.nodes[addr] = d
+	} else if !d.last.IsZero() {
+		d.add(float64(now.Sub(d.last).Milliseconds()), f.cfg.MaxSamples)
+	}
+	d.last = now
+	changed := !d.available
+	d.available = true
+	f.mu.Unlock()
+
+	if changed {
+		f.notify(addr, true)
+	}
+}
+
+// attach 由新连接接管addr的心跳来源并重置之前的统计，返回来源标识
+// 同一节点的多个连接中只有最新的连接驱动检测，避免多路心跳扭曲间隔统计
+// 接管时刻视为一次心跳，首个pong到达前对端即失去响应也能被判定失效
+func (f *FailureDetector) attach(addr string) uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextSource++
+	d := newPhiDetector(&f.cfg)
+	d.source = f.nextSource
+	d.last = time.Now()
+	f.nodes[addr] = d
+	return d.source
+}
+
+// heartbeatFrom 记录来自连接source的心跳，source不再是当前来源时忽略
+func (f *FailureDetector) heartbeatFrom(addr string, source uint64) {
+	if !f.owns(addr, source) {
+		return
+	}
+	f.Heartbeat(addr)
+}
+
+// owns 判断source是否为addr当前的心跳来源
+func (f *FailureDetector) owns(addr string, source uint64) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	d, ok := f.nodes[addr]
+	return ok && d.source == source
+}
+
+// detach 连接关闭时移除addr的检测状态，addr已由其他连接接管时保留
+func (f *FailureDetector) detach(addr string, source uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if d, ok := f.nodes[addr]; ok && d.source == source {
+		delete(f.nodes, addr)
+	}
+}
+
+// Phi 返回addr当前的怀疑程度，未知节点返回0
+func (f *FailureDetector) Phi(addr string) float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if d, ok := f.nodes[addr]; ok {
+		return d.phi(time.Now(), &f.cfg)
+	}
+	return 0
+}
+
+// IsAvailable 判断addr是否可用，状态变化时通知订阅者
+func (f *FailureDetector) IsAvailable(addr string) bool {
+	f.mu.Lock()
+	d, ok := f.nodes[addr]
+	if !ok {
+		f.mu.Unlock()
+		return true
+	}
+	available := d.phi(time.Now(), &f.cfg) < f.cfg.Threshold
+	changed := available != d.available
+	d.available = available
+	f.mu.Unlock()
+
+	if changed {
+		f.notify(addr, available)
+	}
+	return available
+}
+
+// Suspicion 返回所有已知节点当前的phi值
+func (f *FailureDetector) Suspicion() map[string]float64 {
+	now := time.Now()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	result := make(map[string]float64, len(f.nodes))
+	for addr, d := range f.nodes {
+		result[addr] = d.phi(now, &f.cfg)
+	}
+	return result
+}
+
+// Remove 移除addr的检测状态
+func (f *FailureDetector) Remove(addr string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.nodes, addr)
+}
+
+// Subscribe 订阅节点可用状态变化，供重连或监视逻辑使用
+func (f *FailureDetector) Subscribe(fn func(addr string, available bool)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.listeners = append(f.listeners, fn)
+}
+
+func (f *FailureDetector) notify(addr string, available bool) {
+	f.mu.Lock()
+	listeners := append([]func(string, bool){}, f.listeners...)
+	f.mu.Unlock()
+	for _, fn := range listeners {
+		fn(addr, available)
+	}
+}
@@ -0,0 +1,171 @@
+package actor
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func fastDetector() *FailureDetector {
+	return NewFailureDetector(FailureDetectorConfig{
+		MinStdDeviation:        5 * time.Millisecond,
+		FirstHeartbeatEstimate: 20 * time.Millisecond,
+	})
+}
+
+func TestFailureDetectorPhi(t *testing.T) {
+	fd := fastDetector()
+	var mu sync.Mutex
+	var changes []bool
+	fd.Subscribe(func(addr string, available bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		changes = append(changes, available)
+	})
+
+	if !fd.IsAvailable("a") || fd.Phi("a") != 0 {
+		t.Fatal("unknown node should be available")
+	}
+	for i := 0; i < 5; i++ {
+		fd.Heartbeat("a")
+		time.Sleep(20 * time.Millisecond)
+	}
+	if !fd.IsAvailable("a") {
+		t.Fatalf("regular heartbeats suspected, phi %.2f", fd.Phi("a"))
+	}
+	eventually(t, "suspicion", func() bool { return !fd.IsAvailable("a") })
+	fd.Heartbeat("a")
+	if !fd.IsAvailable("a") {
+		t.Fatal("heartbeat did not restore availability")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(changes) != 2 || changes[0] || !changes[1] {
+		t.Fatalf("availability changes %v, want [false true]", changes)
+	}
+}
+
+func TestFailureDetectorSources(t *testing.T) {
+	fd := fastDetector()
+	first := fd.attach("n")
+	second := fd.attach("n")
+	if fd.owns("n", first) || !fd.owns("n", second) {
+		t.Fatal("newest connection should own the node")
+	}
+
+	fd.heartbeatFrom("n", first) // 旧连接的心跳被忽略
+	fd.mu.Lock()
+	samples := len(fd.nodes["n"].intervals)
+	fd.mu.Unlock()
+	if samples != 2 {
+		t.Fatal("heartbeat from stale source was recorded")
+	}
+
+	fd.detach("n", first)
+	if _, ok := fd.Suspicion()["n"]; !ok {
+		t.Fatal("stale source removed the current entry")
+	}
+	fd.detach("n", second)
+	if _, ok := fd.Suspicion()["n"]; ok {
+		t.Fatal("entry not removed on close")
+	}
+}
+
+func TestFailureDetectorResetOnReconnect(t *testing.T) {
+	sys := NewActorSystem()
+	_, _ = sys.RegisterActor("echo", echo)
+	_, addr := startServer(t, sys)
+	fd := fastDetector()
+
+	// 之前的连接已将地址判定为失效
+	stale := fd.attach(addr)
+	fd.heartbeatFrom(addr, stale)
+	eventually(t, "stale suspicion", func() bool { return !fd.IsAvailable(addr) })
+
+	ref := dial(t, "echo", addr, WithHeartbeat(20*time.Millisecond, 3), WithFailureDetector(fd))
+	time.Sleep(200 * time.Millisecond)
+	var out int
+	if err := ref.Request(testContext(t), 1, &out); err != nil || out != 1 {
+		t.Fatalf("reconnected link failed: %v", err)
+	}
+	if !fd.IsAvailable(addr) {
+		t.Fatalf("reconnected node suspected, phi %.2f", fd.Phi(addr))
+	}
+
+	ref.(*remoteActorRef).Close()
+	if _, ok := fd.Suspicion()[addr]; ok {
+		t.Fatal("detector entry kept after close")
+	}
+}
+
+func TestServerFailureDetectorForgetsClosedClients(t *testing.T) {
+	sys := NewActorSystem()
+	_, _ = sys.RegisterActor("echo", echo)
+	fd := fastDetector()
+	_, addr := startServer(t, sys, WithServerHeartbeat(20*time.Millisecond, 3), WithServerFailureDetector(fd))
+
+	for i := 0; i < 5; i++ {
+		ref, err := NewRemoteActorRef("echo", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ref.Request(testContext(t), i, new(int)); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(50 * time.Millisecond) // 至少完成一次ping/pong
+		if len(fd.Suspicion()) == 0 {
+			t.Fatal("open connection not tracked")
+		}
+		ref.(*remoteActorRef).Close()
+	}
+	eventually(t, "entries removed", func() bool { return len(fd.Suspicion()) == 0 })
+}
+
+// freezingProxy 转发到target，调用freeze后不再转发服务端数据，模拟半开连接
+func freezingProxy(t *testing.T, target string) (addr string, freeze func()) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	frozen := make(chan struct{})
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		u, err := net.Dial("tcp", target)
+		if err != nil {
+			return
+		}
+		defer u.Close()
+		go func() {
+			buf := make([]byte, 4096)
+			for {
+				n, err := u.Read(buf)
+				if err != nil {
+					return
+				}
+				select {
+				case <-frozen:
+					continue
+				default:
+				}
+				_, _ = c.Write(buf[:n])
+			}
+		}()
+		buf := make([]byte, 4096)
+		for {
+			n, err := c.Read(buf)
+			if err != nil {
+				return
+			}
+			_, _ = u.Write(buf[:n])
+		}
+	}()
+	var once sync.Once
+	return ln.Addr().String(), func() { once.Do(func() { close(frozen) }) }
+}
@@ -0,0 +1,71 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrBackpressure 发送队列已满或对端没有剩余额度，在调用方的超时内无法发送
+var ErrBackpressure = errors.New("backpressure: send queue is full")
+
+// DefaultConnectionCredits 服务端为每个连接授予的默认消息额度
+const DefaultConnectionCredits = 1024
+
+// flowControl 客户端的发送额度，服务端通过额度帧告知还能接收多少条消息
+type flowControl struct {
+	mu        sync.Mutex
+	enabled   bool // 服务端未授予额度时不限制
+	available int64
+	notify    chan struct{} // 额度增加时关闭并替换
+}
+
+func newFlowControl(initial int64) *flowControl {
+	return &flowControl{
+		enabled:   initial > 0,
+		available: initial,
+		notify:    make(chan struct{}),
+	}
+}
+
+// acquire 获取一条消息的额度，额度不足时等待直到ctx结束
+func (fc *flowControl) acquire(ctx context.Context, closed <-chan struct{}) error {
+	for {
+		fc.mu.Lock()
+		if !fc.enabled || fc.available > 0 {
+			fc.available--
+			fc.mu.Unlock()
+			return nil
+		}
+		wait := fc.notify
+		fc.mu.Unlock()
+
+		select {
+		case <-wait:
+		case <-closed:
+			return ErrConnectionClosed
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ErrBackpressure, ctx.Err())
+		}
+	}
+}
+
+// grant 增加额度并唤醒等待者
+func (fc *flowControl) grant(n int64) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.available += n
+	close(fc.notify)
+	fc.notify = make(chan struct{})
+}
+
+// remaining 剩余额度，未启用流控时返回-1
+func (fc *flowControl) remaining() int64 {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if !fc.enabled {
+		return -1
+	}
+	return fc.available
+}
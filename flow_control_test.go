@@ -0,0 +1,73 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestConnectionCredits(t *testing.T) {
+	sys := NewActorSystem()
+	release := make(chan struct{})
+	started := make(chan struct{}, 3)
+	for _, id := range []string{"slow0", "slow1", "slow2"} {
+		if _, err := sys.RegisterActor(id, func(msg interface{}) (interface{}, error) {
+			started <- struct{}{}
+			<-release
+			return nil, nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, addr := startServer(t, sys, WithConnectionCredits(3))
+	ref := dial(t, "slow0", addr, WithSendTimeout(50*time.Millisecond))
+	rr := ref.(RemoteActorRef)
+	if n := rr.Credits(); n != 3 {
+		t.Fatalf("initial credits %d, want 3", n)
+	}
+
+	done := make(chan error, 3)
+	ctx := testContext(t)
+	for i := 0; i < 3; i++ {
+		// 额度按连接计算，发往不同actor的请求共享同一额度
+		target := &selectedRemoteRef{conn: ref.(*remoteActorRef), id: fmt.Sprint("slow", i)}
+		go func() { done <- target.Request(ctx, "x", new(int)) }()
+	}
+	for i := 0; i < 3; i++ {
+		<-started
+	}
+	if n := rr.Credits(); n != 0 {
+		t.Fatalf("credits %d with 3 requests in flight, want 0", n)
+	}
+
+	// 额度用尽时在调用方的超时内返回 ErrBackpressure，而不是无限阻塞
+	short, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := ref.Request(short, "x", new(int)); !errors.Is(err, ErrBackpressure) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("request without credits: %v", err)
+	}
+	if err := ref.Tell("x"); !errors.Is(err, ErrBackpressure) {
+		t.Fatalf("tell without credits: %v", err)
+	}
+
+	close(release)
+	for i := 0; i < 3; i++ {
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+	}
+	eventually(t, "credits returned", func() bool { return rr.Credits() == 3 })
+	if err := ref.Tell("x"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreditsDisabled(t *testing.T) {
+	sys := NewActorSystem()
+	_, addr := startServer(t, sys, WithConnectionCredits(0))
+	if n := dial(t, "any", addr).(RemoteActorRef).Credits(); n != -1 {
+		t.Fatalf("credits %d without flow control, want -1", n)
+	}
+}
@@ -0,0 +1,411 @@
+package actor
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// 协议常量
+const (
+	protocolMagic   = "ACTR" // 连接前导魔数
+	ProtocolVersion = 2      // 当前协议版本
+
+	frameHeaderSize  = 14       // 长度(4) + 类型(1) + 标志(1) + ID(8)
+	maxFrameSize     = 64 << 20 // 单帧负载的最大长度
+	maxHandshakeSize = 64 << 10 // 握手与认证帧负载的最大长度，认证前的连接不应占用大块内存
+	handshakeTimeout = time.Second * 10
+)
+
+var (
+	ErrProtocolMismatch = errors.New("protocol mismatch")
+	ErrLegacyProtocol   = errors.New("legacy JSON protocol is not supported")
+)
+
+// frameType 帧类型
+type frameType uint8
+
+const (
+	frameHandshake frameType = iota + 1
+	frameHandshakeAck
+	frameRequest
+	frameTell
+	frameResponse
+	frameAuth
+	frameAuthResult
+	framePing
+	framePong
+	frameCancel
+	frameTellAck // 需要投递确认的单向消息，服务端以响应帧回复确认或拒绝原因
+	frameCredit  // 服务端授予的额外消息额度，数量由帧ID携带
+
+	frameStreamRequest // 流式请求，服务端以若干分块帧和一个结束帧回复
+	frameStreamChunk   // 流式响应的一个分块
+	frameStreamEnd     // 流式响应结束，负载携带错误信息
+	frameStreamCredit  // 客户端归还的流窗口，数量由负载携带
+
+	frameUploadRequest // 上传流请求，客户端随后发送若干分块帧和一个结束帧，服务端以响应帧回复
+	frameUploadChunk   // 上传流的一个分块
+	frameUploadEnd     // 上传流结束
+	frameUploadCredit  // 服务端归还的上传窗口，数量由负载携带
+
+	frameSpawn      // 按类型在服务端创建actor，负载为创建参数，服务端以响应帧回复
+	frameList       // 查询服务端的actor列表，负载为 ListOptions
+	frameSelectTell // 向Target通配符匹配的全部actor投递单向消息，服务端回复投递数量
+)
+
+// consumesCredit 该类型的帧是否占用发送额度
+func (t frameType) consumesCredit() bool {
+	return t == frameRequest || t == frameTell || t == frameTellAck || t == frameStreamRequest || t == frameUploadRequest || t == frameSpawn || t == frameList || t == frameSelectTell
+}
+
+// expectsReply 该类型的帧是否需要服务端回复响应帧
+func (t frameType) expectsReply() bool {
+	return t == frameRequest || t == frameTellAck || t == frameStreamRequest || t == frameUploadRequest || t == frameSpawn || t == frameList || t == frameSelectTell
+}
+
+// frame 表示一个协议帧
+type frame struct {
+	Type    frameType
+	Flags   uint8
+	ID      uint64
+	Payload []byte
+}
+
+// handshakeRequest 客户端握手请求，握手阶段固定使用JSON
+type handshakeRequest struct {
+	Version      int      `json:"version"`
+	Codecs       []string `json:"codecs"`
+	NodeID       string   `json:"node_id"`
+	Compressions []string `json:"compressions,omitempty"`
+}
+
+// handshakeResponse 服务端握手响应
+type handshakeResponse struct {
+	Version int    `json:"version"`
+	Codec   string `json:"codec,omitempty"`
+	NodeID  string `json:"node_id,omitempty"`
+	Error   string `json:"error,omitempty"`
+
+	// Compression 选定的压缩算法，为空表示不压缩
+	Compression string `json:"compression,omitempty"`
+
+	// Credits 服务端授予的初始消息额度，0表示不做流控
+	Credits int64 `json:"credits,omitempty"`
+
+	// Challenge 非空时客户端必须发送认证帧
+	Challenge []byte `json:"challenge,omitempty"`
+}
+
+// writeFrame 写入一帧，调用方负责刷新
+func writeFrame(w *bufio.Writer, f frame) error {
+	var head [frameHeaderSize]byte
+	binary.BigEndian.PutUint32(head[0:4], uint32(len(f.Payload)))
+	head[4] = byte(f.Type)
+	head[5] = f.Flags
+	binary.BigEndian.PutUint64(head[6:14], f.ID)
+	if _, err := w.Write(head[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(f.Payload)
+	return err
+}
+
+// readFrame 读取一帧
+func readFrame(r *bufio.Reader) (frame, error) {
+	return readFrameLimit(r, maxFrameSize)
+}
+
+// readFrameLimit 读取一帧，负载超过limit时在分配内存前返回错误
+func readFrameLimit(r *bufio.Reader, limit uint32) (frame, error) {
+	var head [frameHeaderSize]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return frame{}, err
+	}
+	size := binary.BigEndian.Uint32(head[0:4])
+	if size > limit {
+		return frame{}, fmt.Errorf("frame too large: %d", size)
+	}
+	f := frame{
+		Type:  frameType(head[4]),
+		Flags: head[5],
+		ID:    binary.BigEndian.Uint64(head[6:14]),
+	}
+	if size > 0 {
+		f.Payload = make([]byte, size)
+		if _, err := io.ReadFull(r, f.Payload); err != nil {
+			return frame{}, err
+		}
+	}
+	return f, nil
+}
+
+// writePreface 写入连接前导: 魔数 + 协议版本
+func writePreface(w *bufio.Writer) error {
+	if _, err := w.WriteString(protocolMagic); err != nil {
+		return err
+	}
+	return w.WriteByte(ProtocolVersion)
+}
+
+// readPreface 读取并校验连接前导，返回对端的协议版本
+func readPreface(r *bufio.Reader) (int, error) {
+	first, err := r.Peek(1)
+	if err != nil {
+		return 0, err
+	}
+	if first[0] == '{' {
+		return 0, ErrLegacyProtocol
+	}
+	var buf [len(protocolMagic) + 1]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return 0, err
+	}
+	if string(buf[:len(protocolMagic)]) != protocolMagic {
+		return 0, ErrProtocolMismatch
+	}
+	return int(buf[len(protocolMagic)]), nil
+}
+
+// writeJSONFrame 以JSON编码写入握手类帧并刷新
+func writeJSONFrame(w *bufio.Writer, typ frameType, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := writeFrame(w, frame{Type: typ, Payload: data}); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// readJSONFrame 读取指定类型的握手类帧
+func readJSONFrame(r *bufio.Reader, typ frameType, v interface{}) error {
+	f, err := readFrameLimit(r, maxHandshakeSize)
+	if err != nil {
+		return err
+	}
+	if f.Type != typ {
+		return fmt.Errorf("%w: unexpected frame type %d", ErrProtocolMismatch, f.Type)
+	}
+	return json.Unmarshal(f.Payload, v)
+}
+
+// handshakeResult 握手结果
+type handshakeResult struct {
+	codec      Codec
+	compressor Compressor
+	peerNodeID string
+	credits    int64
+}
+
+// clientAuthenticate 响应服务端挑战
+func clientAuthenticate(r *bufio.Reader, w *bufio.Writer, creds Credentials, challenge []byte) error {
+	req := &AuthRequest{}
+	if creds != nil {
+		var err error
+		if req, err = creds.Authenticate(challenge); err != nil {
+			return err
+		}
+	}
+	if err := writeJSONFrame(w, frameAuth, req); err != nil {
+		return err
+	}
+	var res authResult
+	if err := readJSONFrame(r, frameAuthResult, &res); err != nil {
+		return err
+	}
+	if res.Error != nil {
+		return res.Error
+	}
+	return nil
+}
+
+// serverAuthenticate 下发挑战后校验客户端凭证，并将身份写入peer
+func serverAuthenticate(r *bufio.Reader, w *bufio.Writer, auth Authenticator, challenge []byte, peer *Peer) error {
+	var req AuthRequest
+	if err := readJSONFrame(r, frameAuth, &req); err != nil {
+		return err
+	}
+	principal, err := auth.Authenticate(peer, challenge, &req)
+	if err != nil {
+		if !errors.Is(err, ErrUnauthenticated) {
+			err = fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+		}
+		_ = writeJSONFrame(w, frameAuthResult, authResult{Error: toError(err)})
+		return err
+	}
+	peer.Principal = principal
+	return writeJSONFrame(w, frameAuthResult, authResult{Principal: principal.Name})
+}
+
+// clientHandshake 发送前导与握手请求，返回服务端选定的编解码器
+func clientHandshake(r *bufio.Reader, w *bufio.Writer, o *remoteOptions) (*handshakeResult, error) {
+	if err := writePreface(w); err != nil {
+		return nil, fmt.Errorf("handshake failed: %w", err)
+	}
+	req := handshakeRequest{
+		Version:      ProtocolVersion,
+		Codecs:       o.codecs,
+		NodeID:       o.nodeID,
+		Compressions: o.compressions,
+	}
+	if err := writeJSONFrame(w, frameHandshake, req); err != nil {
+		return nil, fmt.Errorf("handshake failed: %w", err)
+	}
+	var resp handshakeResponse
+	if err := readJSONFrame(r, frameHandshakeAck, &resp); err != nil {
+		return nil, fmt.Errorf("handshake failed: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("handshake rejected: %s", resp.Error)
+	}
+	codec, ok := GetCodec(resp.Codec)
+	if !ok {
+		return nil, fmt.Errorf("handshake failed: unknown codec %q", resp.Codec)
+	}
+	hs := &handshakeResult{codec: codec, peerNodeID: resp.NodeID, credits: resp.Credits}
+	if resp.Compression != "" {
+		if hs.compressor, ok = GetCompressor(resp.Compression); !ok {
+			return nil, fmt.Errorf("handshake failed: unknown compression %q", resp.Compression)
+		}
+	}
+	if len(resp.Challenge) > 0 {
+		if err := clientAuthenticate(r, w, o.credentials, resp.Challenge); err != nil {
+			return nil, fmt.Errorf("handshake rejected: %w", err)
+		}
+	}
+	return hs, nil
+}
+
+// serverHandshake 校验前导并完成握手协商，对端节点标识与认证身份写入peer
+func serverHandshake(r *bufio.Reader, w *bufio.Writer, o *serverOptions, peer *Peer) (*handshakeResult, error) {
+	version, err := readPreface(r)
+	if err != nil {
+		if errors.Is(err, ErrLegacyProtocol) {
+			// 旧版JSON客户端: 返回一条它能解析的错误后关闭
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": 0, "error": err.Error()})
+			_ = w.Flush()
+		}
+		return nil, err
+	}
+
+	reject := func(err error) (*handshakeResult, error) {
+		_ = writeJSONFrame(w, frameHandshakeAck, handshakeResponse{Version: ProtocolVersion, Error: err.Error()})
+		return nil, err
+	}
+	if version != ProtocolVersion {
+		return reject(fmt.Errorf("%w: unsupported version %d", ErrProtocolMismatch, version))
+	}
+
+	var req handshakeRequest
+	if err := readJSONFrame(r, frameHandshake, &req); err != nil {
+		return nil, err
+	}
+	codec, err := negotiateCodec(req.Codecs, o.codecs)
+	if err != nil {
+		return reject(err)
+	}
+	compressor := negotiateCompressor(req.Compressions, o.compressions)
+	resp := handshakeResponse{
+		Version: ProtocolVersion,
+		Codec:   codec.Name(),
+		NodeID:  o.nodeID,
+		Credits: int64(o.credits),
+	}
+	if compressor != nil {
+		resp.Compression = compressor.Name()
+	}
+	if o.authenticator != nil {
+		resp.Challenge = make([]byte, 32)
+		_, _ = rand.Read(resp.Challenge)
+	}
+	if err := writeJSONFrame(w, frameHandshakeAck, resp); err != nil {
+		return nil, err
+	}
+	peer.NodeID = req.NodeID
+	if o.authenticator != nil {
+		if err := serverAuthenticate(r, w, o.authenticator, resp.Challenge, peer); err != nil {
+			return nil, err
+		}
+	}
+	return &handshakeResult{codec: codec, compressor: compressor, peerNodeID: req.NodeID}, nil
+}
+
+// newNodeID 生成随机节点标识
+func newNodeID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// wireConn 封装连接上协商得到的编码与压缩方式
+type wireConn struct {
+	codec      Codec
+	compressor Compressor // 为nil时不压缩
+	threshold  int
+	stats      *linkStats
+}
+
+// pack 构造一帧，负载超过阈值时压缩
+func (c *wireConn) pack(typ frameType, id uint64, payload []byte) frame {
+	f := frame{Type: typ, ID: id, Payload: payload}
+	if c.compressor != nil && len(payload) >= c.threshold {
+		if data, err := c.compressor.Compress(payload); err == nil && len(data) < len(payload) {
+			f.Payload = data
+			f.Flags |= flagCompressed
+		}
+	}
+	c.stats.rawSent.Add(int64(len(payload)))
+	c.stats.wireSent.Add(int64(len(f.Payload)))
+	return f
+}
+
+// unpack 返回帧的原始负载
+func (c *wireConn) unpack(f frame) ([]byte, error) {
+	payload := f.Payload
+	if f.Flags&flagCompressed != 0 {
+		if c.compressor == nil {
+			return nil, fmt.Errorf("%w: unexpected compressed frame", ErrProtocolMismatch)
+		}
+		var err error
+		if payload, err = c.compressor.Decompress(f.Payload); err != nil {
+			return nil, fmt.Errorf("decompress failed: %w", err)
+		}
+	}
+	c.stats.rawReceived.Add(int64(len(payload)))
+	c.stats.wireReceived.Add(int64(len(f.Payload)))
+	return payload, nil
+}
+
+// encodeMessage 编码远程消息并构造帧
+func (c *wireConn) encodeMessage(typ frameType, id uint64, m *remoteMessage) (frame, error) {
+	data, err := c.codec.Marshal(m)
+	if err != nil {
+		return frame{}, err
+	}
+	return c.pack(typ, id, data), nil
+}
+
+// decodeMessage 解包帧并解码远程消息及其负载
+func (c *wireConn) decodeMessage(f frame) (*remoteMessage, interface{}, error) {
+	data, err := c.unpack(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	var m remoteMessage
+	if err := c.codec.Unmarshal(data, &m); err != nil {
+		return nil, nil, err
+	}
+	payload, err := m.decode(c.codec)
+	if err != nil {
+		return &m, nil, err
+	}
+	return &m, payload, nil
+}
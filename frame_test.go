@@ -0,0 +1,137 @@
+package actor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	frames := []frame{
+		{Type: frameRequest, ID: 1, Payload: []byte("hello")},
+		{Type: frameTell, Flags: 1, ID: 1 << 40},
+		{Type: frameCredit, ID: 7},
+		{Type: frameResponse, ID: 2, Payload: bytes.Repeat([]byte{0xff}, 4096)},
+	}
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	for _, f := range frames {
+		if err := writeFrame(w, f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(&buf)
+	for _, want := range frames {
+		got, err := readFrame(r)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %+v %v, want %+v", got, err, want)
+		}
+	}
+	if _, err := readFrame(r); err != io.EOF {
+		t.Fatalf("read past the last frame: %v", err)
+	}
+}
+
+func TestReadFrameRejectsOversizedLength(t *testing.T) {
+	var head [frameHeaderSize]byte
+	binary.BigEndian.PutUint32(head[0:4], maxFrameSize+1)
+	head[4] = byte(frameRequest)
+	if _, err := readFrame(bufio.NewReader(bytes.NewReader(head[:]))); err == nil || !strings.Contains(err.Error(), "frame too large") {
+		t.Fatalf("oversized frame accepted: %v", err)
+	}
+
+	// 握手帧使用更小的上限
+	binary.BigEndian.PutUint32(head[0:4], maxHandshakeSize+1)
+	head[4] = byte(frameHandshake)
+	var req handshakeRequest
+	if err := readJSONFrame(bufio.NewReader(bytes.NewReader(head[:])), frameHandshake, &req); err == nil || !strings.Contains(err.Error(), "frame too large") {
+		t.Fatalf("oversized handshake frame accepted: %v", err)
+	}
+}
+
+func TestReadPreface(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		version int
+		err     error
+	}{
+		{"current", protocolMagic + "\x02", ProtocolVersion, nil},
+		{"other version", protocolMagic + "\x07", 7, nil},
+		{"legacy json", `{"id":1}`, 0, ErrLegacyProtocol},
+		{"wrong magic", "HTTP/1.1", 0, ErrProtocolMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := readPreface(bufio.NewReader(strings.NewReader(tt.input)))
+			if !errors.Is(err, tt.err) || v != tt.version {
+				t.Fatalf("got %d %v, want %d %v", v, err, tt.version, tt.err)
+			}
+		})
+	}
+}
+
+func TestServerRejectsUnsupportedClients(t *testing.T) {
+	_, addr := startServer(t, NewActorSystem())
+
+	tests := []struct {
+		name  string
+		hello []byte
+		check func(t *testing.T, reply []byte)
+	}{
+		{
+			name:  "legacy json client",
+			hello: []byte(`{"id":1,"target":"a","type":"Request"}`),
+			check: func(t *testing.T, reply []byte) {
+				var resp map[string]interface{}
+				if err := json.Unmarshal(reply, &resp); err != nil || !strings.Contains(resp["error"].(string), "legacy") {
+					t.Fatalf("legacy client got %q: %v", reply, err)
+				}
+			},
+		},
+		{
+			name:  "unsupported version",
+			hello: []byte(protocolMagic + "\x01"),
+			check: func(t *testing.T, reply []byte) {
+				f, err := readFrame(bufio.NewReader(bytes.NewReader(reply)))
+				if err != nil || f.Type != frameHandshakeAck {
+					t.Fatalf("got %+v %v", f, err)
+				}
+				var resp handshakeResponse
+				if err := json.Unmarshal(f.Payload, &resp); err != nil || !strings.Contains(resp.Error, "unsupported version 1") {
+					t.Fatalf("got %+v %v", resp, err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := net.Dial("tcp", addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+			if _, err := conn.Write(tt.hello); err != nil {
+				t.Fatal(err)
+			}
+			// 服务端回复错误后关闭连接
+			reply, err := io.ReadAll(conn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, reply)
+		})
+	}
+}
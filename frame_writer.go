@@ -0,0 +1,126 @@
+package actor
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// ErrConnectionClosed 连接已关闭
+var ErrConnectionClosed = errors.New("connection is closed")
+
+const (
+	defaultWriteQueueSize = 100
+	defaultWriteTimeout   = time.Second * 10
+	maxBatchBytes         = 32 * 1024 // 单次批量写入后强制刷新的字节数
+)
+
+// frameWriter 连接的专用写goroutine
+// 队列为空时立即刷新；负载高时将队列中已有的帧合并为一次刷新
+type frameWriter struct {
+	conn    net.Conn
+	w       *bufio.Writer
+	queue   chan frame
+	timeout time.Duration // 每次刷新的写超时，0表示不设置
+	onError func(error)
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newFrameWriter(conn net.Conn, w *bufio.Writer, size int, timeout time.Duration, onError func(error)) *frameWriter {
+	if size <= 0 {
+		size = defaultWriteQueueSize
+	}
+	return &frameWriter{
+		conn:    conn,
+		w:       w,
+		queue:   make(chan frame, size),
+		timeout: timeout,
+		onError: onError,
+		done:    make(chan struct{}),
+	}
+}
+
+// run 写循环，直到close或写失败
+func (fw *frameWriter) run() {
+	for {
+		select {
+		case <-fw.done:
+			return
+		case f := <-fw.queue:
+			if err := fw.writeBatch(f); err != nil {
+				fw.close()
+				if fw.onError != nil {
+					fw.onError(err)
+				}
+				return
+			}
+		}
+	}
+}
+
+// writeBatch 写入f及队列中已就绪的帧后刷新
+func (fw *frameWriter) writeBatch(f frame) error {
+	if fw.timeout > 0 {
+		_ = fw.conn.SetWriteDeadline(time.Now().Add(fw.timeout))
+	}
+	if err := writeFrame(fw.w, f); err != nil {
+		return err
+	}
+	for fw.w.Buffered() < maxBatchBytes {
+		select {
+		case next := <-fw.queue:
+			if err := writeFrame(fw.w, next); err != nil {
+				return err
+			}
+			continue
+		default:
+		}
+		break
+	}
+	return fw.w.Flush()
+}
+
+// enqueue 将帧放入写队列，队列已满时阻塞直到可写或连接关闭
+func (fw *frameWriter) enqueue(f frame) error {
+	return fw.enqueueContext(context.Background(), f)
+}
+
+// enqueueContext 将帧放入写队列，队列已满且ctx结束时返回 ErrBackpressure
+func (fw *frameWriter) enqueueContext(ctx context.Context, f frame) error {
+	select {
+	case <-fw.done:
+		return ErrConnectionClosed
+	default:
+	}
+	select {
+	case fw.queue <- f:
+		return nil
+	case <-fw.done:
+		return ErrConnectionClosed
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %w", ErrBackpressure, ctx.Err())
+	}
+}
+
+// tryEnqueue 尝试将帧放入写队列，队列已满时丢弃
+func (fw *frameWriter) tryEnqueue(f frame) bool {
+	select {
+	case fw.queue <- f:
+		return true
+	default:
+		return false
+	}
+}
+
+// close 停止写循环
+func (fw *frameWriter) close() {
+	fw.closeOnce.Do(func() {
+		close(fw.done)
+	})
+}
@@ -0,0 +1,122 @@
+package actor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingConn 记录每次Write的连接，可以模拟写失败
+type recordingConn struct {
+	net.Conn
+	mu     sync.Mutex
+	writes [][]byte
+	err    error
+}
+
+func (c *recordingConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return 0, c.err
+	}
+	c.writes = append(c.writes, append([]byte(nil), p...))
+	return len(p), nil
+}
+
+func (c *recordingConn) SetWriteDeadline(time.Time) error { return nil }
+
+func (c *recordingConn) flushes() [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([][]byte(nil), c.writes...)
+}
+
+func TestFrameWriterBatchesQueuedFrames(t *testing.T) {
+	conn := &recordingConn{}
+	fw := newFrameWriter(conn, bufio.NewWriterSize(conn, 64*1024), 16, 0, nil)
+	defer fw.close()
+	for i := uint64(1); i <= 10; i++ {
+		if err := fw.enqueue(frame{Type: frameTell, ID: i, Payload: []byte("x")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	go fw.run()
+
+	eventually(t, "frames flushed", func() bool { return len(conn.flushes()) > 0 })
+	writes := conn.flushes()
+	if len(writes) != 1 {
+		t.Fatalf("%d flushes for queued frames, want 1", len(writes))
+	}
+	r := bufio.NewReader(bytes.NewReader(writes[0]))
+	for i := uint64(1); i <= 10; i++ {
+		f, err := readFrame(r)
+		if err != nil || f.ID != i {
+			t.Fatalf("frame %d: %+v %v", i, f, err)
+		}
+	}
+
+	// 队列为空时单帧立即刷新
+	if err := fw.enqueue(frame{Type: frameTell, ID: 11}); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "single frame flushed", func() bool { return len(conn.flushes()) == 2 })
+}
+
+func TestFrameWriterQueue(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(fw *frameWriter, conn *recordingConn)
+		err   error
+	}{
+		{
+			name:  "full queue",
+			setup: func(fw *frameWriter, _ *recordingConn) { _ = fw.enqueue(frame{Type: frameTell}) },
+			err:   ErrBackpressure,
+		},
+		{
+			name:  "closed",
+			setup: func(fw *frameWriter, _ *recordingConn) { fw.close() },
+			err:   ErrConnectionClosed,
+		},
+		{
+			name: "write failed",
+			setup: func(fw *frameWriter, conn *recordingConn) {
+				conn.mu.Lock()
+				conn.err = errors.New("broken pipe")
+				conn.mu.Unlock()
+				go fw.run()
+				_ = fw.enqueue(frame{Type: frameTell})
+				<-fw.done
+			},
+			err: ErrConnectionClosed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &recordingConn{}
+			failed := make(chan error, 1)
+			fw := newFrameWriter(conn, bufio.NewWriter(conn), 1, 0, func(err error) { failed <- err })
+			defer fw.close()
+			tt.setup(fw, conn)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			if err := fw.enqueueContext(ctx, frame{Type: frameTell}); !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+			if tt.name == "write failed" {
+				select {
+				case <-failed:
+				case <-time.After(5 * time.Second):
+					t.Fatal("write error was not reported")
+				}
+			}
+		})
+	}
+}
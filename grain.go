@@ -0,0 +1,388 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 虚拟actor默认配置
+const (
+	DefaultGrainIdleTimeout = 5 * time.Minute
+	grainPrefix             = "grain/"
+	grainStoreTimeout       = 10 * time.Second
+)
+
+// GrainStore 虚拟actor在两次激活之间的状态存储
+type GrainStore interface {
+	// Load 读取状态，没有状态时返回nil
+	Load(ctx context.Context, kind, key string) ([]byte, error)
+	// Save 写入状态
+	Save(ctx context.Context, kind, key string, data []byte) error
+}
+
+// MemoryGrainStore 进程内的状态存储，适用于测试或不需要跨进程保留状态的场景
+type MemoryGrainStore struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+}
+
+// NewMemoryGrainStore 创建进程内的状态存储
+func NewMemoryGrainStore() *MemoryGrainStore {
+	return &MemoryGrainStore{data: make(map[string][]byte)}
+}
+
+// Load 实现GrainStore
+func (m *MemoryGrainStore) Load(_ context.Context, kind, key string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.data[kind+"/"+key], nil
+}
+
+// Save 实现GrainStore
+func (m *MemoryGrainStore) Save(_ context.Context, kind, key string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[kind+"/"+key] = data
+	return nil
+}
+
+// GrainState 虚拟actor的持久化状态，只应在该actor的处理函数中访问
+// 修改后的状态在停用时写入存储，也可以通过 Save 立即写入
+type GrainState struct {
+	kind  string
+	key   string
+	codec Codec
+	store GrainStore
+	data  []byte
+	dirty bool
+}
+
+// Get 将状态解码到v，没有状态时返回false
+func (st *GrainState) Get(v interface{}) (bool, error) {
+	if st.data == nil {
+		return false, nil
+	}
+	if err := st.codec.Unmarshal(st.data, v); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Set 编码并替换状态
+func (st *GrainState) Set(v interface{}) error {
+	data, err := st.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	st.data = data
+	st.dirty = true
+	return nil
+}
+
+// Save 立即将修改后的状态写入存储，未配置存储时不做任何事
+func (st *GrainState) Save(ctx context.Context) error {
+	if st.store == nil || !st.dirty {
+		return nil
+	}
+	if err := st.store.Save(ctx, st.kind, st.key, st.data); err != nil {
+		return err
+	}
+	st.dirty = false
+	return nil
+}
+
+// GrainConfig 虚拟actor类型配置
+type GrainConfig struct {
+	Kind        string                                                      // 类型名称
+	Factory     func(key string, state *GrainState) (ContextHandler, error) // 每次激活时创建处理函数
+	IdleTimeout time.Duration                                               // 空闲超过该时间后停用，默认5分钟
+	Store       GrainStore                                                  // 状态存储，为nil时状态不跨激活保留
+	Codec       Codec                                                       // 状态编解码器，默认JSON
+}
+
+// grainKind 一种虚拟actor及其当前的激活
+type grainKind struct {
+	sys    *ActorSystem
+	cfg    GrainConfig
+	prefix string
+
+	mu          sync.Mutex
+	active      map[string]*grainActivation // 按key索引
+	deactivates map[string]chan struct{}    // 正在停用的key，停用完成后关闭
+	unsaved     map[string][]byte           // 停用时写入失败的状态，下次激活时使用并重新写入
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+type grainActivation struct {
+	state      *GrainState
+	handler    ContextHandler
+	lastActive atomic.Int64
+}
+
+// GrainID 返回虚拟actor的actor id，远程调用方可以直接向该id发送消息
+func GrainID(kind, key string) string {
+	return grainPrefix + kind + "/" + key
+}
+
+// RegisterGrain 注册一种虚拟actor
+// 虚拟actor按(kind, key)寻址且逻辑上始终存在：首次收到消息时激活，空闲超时后停用，
+// 停用后的下一条消息会透明地重新激活，配置了存储时状态在两次激活之间保留
+func (s *ActorSystem) RegisterGrain(cfg GrainConfig) error {
+	if cfg.Kind == "" || cfg.Factory == nil {
+		return fmt.Errorf("grain: kind and factory are required")
+	}
+	if strings.Contains(cfg.Kind, "/") {
+		return fmt.Errorf("grain: kind %q must not contain '/'", cfg.Kind)
+	}
+	if cfg.IdleTimeout <= 0 {
+		cfg.IdleTimeout = DefaultGrainIdleTimeout
+	}
+	if cfg.Codec == nil {
+		cfg.Codec = jsonCodec{}
+	}
+	g := &grainKind{
+		sys:         s,
+		cfg:         cfg,
+		prefix:      GrainID(cfg.Kind, ""),
+		active:      make(map[string]*grainActivation),
+		deactivates: make(map[string]chan struct{}),
+		unsaved:     make(map[string][]byte),
+		done:        make(chan struct{}),
+	}
+
+	s.grainsMu.Lock()
+	if _, exists := s.grains[cfg.Kind]; exists {
+		s.grainsMu.Unlock()
+		return fmt.Errorf("grain: kind %q already registered", cfg.Kind)
+	}
+	s.grains[cfg.Kind] = g
+	s.grainsMu.Unlock()
+
+	s.setActivator(g.prefix, activator{kind: cfg.Kind, create: g.activate, rejected: g.redeliver})
+	g.wg.Add(1)
+	go g.loop()
+	return nil
+}
+
+// DeregisterGrain 停用kind类型的全部虚拟actor并保存状态，之后发往该类型的消息返回 ErrActorNotFound
+func (s *ActorSystem) DeregisterGrain(kind string) {
+	s.grainsMu.Lock()
+	g, ok := s.grains[kind]
+	delete(s.grains, kind)
+	s.grainsMu.Unlock()
+	if ok {
+		g.stop()
+	}
+}
+
+// Grain 返回虚拟actor的引用，无需事先注册该actor
+func (s *ActorSystem) Grain(kind, key string) ActorRef {
+	return &grainRef{sys: s, id: GrainID(kind, key)}
+}
+
+// stopGrains 停用所有虚拟actor，在关闭系统时调用
+func (s *ActorSystem) stopGrains() {
+	s.grainsMu.Lock()
+	kinds := make([]*grainKind, 0, len(s.grains))
+	for kind, g := range s.grains {
+		kinds = append(kinds, g)
+		delete(s.grains, kind)
+	}
+	s.grainsMu.Unlock()
+	for _, g := range kinds {
+		g.stop()
+	}
+}
+
+func (g *grainKind) stop() {
+	close(g.done)
+	g.wg.Wait()
+	g.sys.setActivator(g.prefix, activator{})
+	g.deactivate(func(string, *grainActivation) bool {
+		return true
+	})
+}
+
+func (g *grainKind) loop() {
+	defer g.wg.Done()
+	ticker := time.NewTicker(g.cfg.IdleTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			deadline := time.Now().Add(-g.cfg.IdleTimeout).UnixNano()
+			g.deactivate(func(_ string, a *grainActivation) bool {
+				return a.lastActive.Load() < deadline
+			})
+		case <-g.done:
+			return
+		}
+	}
+}
+
+// activate 由ActorSystem在虚拟actor收到消息且未激活时调用，同一key不会并发调用
+func (g *grainKind) activate(id string) (ContextHandler, error) {
+	key := id[len(g.prefix):]
+	// 同一key的上一次停用完成前不激活，避免读到尚未写入的状态；
+	// 停用中的处理函数向自身发送消息时等不到停用完成，超时后返回 ErrActorStopped
+	timeout := time.NewTimer(grainStoreTimeout)
+	defer timeout.Stop()
+	for {
+		g.mu.Lock()
+		ch, ok := g.deactivates[key]
+		if !ok {
+			break
+		}
+		g.mu.Unlock()
+		select {
+		case <-ch:
+		case <-timeout.C:
+			return nil, fmt.Errorf("%w: %s is deactivating", ErrActorStopped, id)
+		}
+	}
+	data, unsaved := g.unsaved[key]
+	g.mu.Unlock()
+
+	state := &GrainState{
+		kind:  g.cfg.Kind,
+		key:   key,
+		codec: g.cfg.Codec,
+		store: g.cfg.Store,
+		data:  data,
+		dirty: unsaved,
+	}
+	if g.cfg.Store != nil && !unsaved {
+		ctx, cancel := context.WithTimeout(context.Background(), grainStoreTimeout)
+		data, err := g.cfg.Store.Load(ctx, g.cfg.Kind, key)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("grain: load %s: %w", id, err)
+		}
+		state.data = data
+	}
+	handler, err := g.cfg.Factory(key, state)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &grainActivation{state: state}
+	a.lastActive.Store(time.Now().UnixNano())
+	a.handler = func(ctx context.Context, msg interface{}) (interface{}, error) {
+		a.lastActive.Store(time.Now().UnixNano())
+		return handler(ctx, msg)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.active[key] = a
+	delete(g.unsaved, key)
+	return a.handler, nil
+}
+
+// redeliver 将停用时邮箱中尚未处理的单向消息投递到下一次激活，无法投递时发布 DeadLetter
+// 重新投递的消息与停用后发送的消息之间不保证顺序
+func (g *grainKind) redeliver(id string, msg interface{}) {
+	ref := &grainRef{sys: g.sys, id: id}
+	if err := ref.Tell(msg); err != nil {
+		g.sys.EventStream().Publish(DeadLetter{Target: id, Message: msg, Err: err})
+	}
+}
+
+// deactivate 停用满足条件的激活，等待处理完已收到的消息后保存状态
+func (g *grainKind) deactivate(match func(key string, a *grainActivation) bool) {
+	stopping := make(map[string]*grainActivation)
+	g.mu.Lock()
+	for key, a := range g.active {
+		if match(key, a) {
+			stopping[key] = a
+			delete(g.active, key)
+			g.deactivates[key] = make(chan struct{})
+		}
+	}
+	g.mu.Unlock()
+
+	for key, a := range stopping {
+		g.sys.DeregisterActor(g.prefix + key)
+		ctx, cancel := context.WithTimeout(context.Background(), grainStoreTimeout)
+		err := a.state.Save(ctx)
+		cancel()
+
+		g.mu.Lock()
+		if err != nil {
+			g.unsaved[key] = a.state.data
+		}
+		close(g.deactivates[key])
+		delete(g.deactivates, key)
+		g.mu.Unlock()
+	}
+}
+
+// grainRef 虚拟actor或匹配激活前缀的actor的引用，发送时按需激活
+type grainRef struct {
+	sys *ActorSystem
+	id  string
+}
+
+// send 向当前激活发送，激活恰好被停用时重新激活
+func (r *grainRef) send(send func(ref ActorRef) error) error {
+	const maxAttempts = 3
+	for attempt := 1; ; attempt++ {
+		actor, err := r.sys.actorFor(r.id)
+		if err == nil {
+			err = send(&localActorRef{actor: actor, id: r.id})
+		}
+		if err == nil || attempt >= maxAttempts || !errors.Is(err, ErrActorStopped) {
+			return err
+		}
+	}
+}
+
+func (r *grainRef) Request(ctx context.Context, req interface{}, resp interface{}) error {
+	return r.send(func(ref ActorRef) error {
+		return ref.Request(ctx, req, resp)
+	})
+}
+
+func (r *grainRef) Tell(msg interface{}) error {
+	return r.send(func(ref ActorRef) error {
+		return ref.Tell(msg)
+	})
+}
+
+func (r *grainRef) TellWithAck(ctx context.Context, msg interface{}) error {
+	return r.send(func(ref ActorRef) error {
+		return ref.TellWithAck(ctx, msg)
+	})
+}
+
+func (r *grainRef) RequestStream(ctx context.Context, req interface{}) (*Stream, error) {
+	var st *Stream
+	err := r.send(func(ref ActorRef) (err error) {
+		st, err = ref.RequestStream(ctx, req)
+		return err
+	})
+	return st, err
+}
+
+func (r *grainRef) RequestUpload(ctx context.Context) (UploadStream, error) {
+	var up UploadStream
+	err := r.send(func(ref ActorRef) (err error) {
+		up, err = ref.RequestUpload(ctx)
+		return err
+	})
+	return up, err
+}
+
+func (r *grainRef) ID() string {
+	return r.id
+}
+
+func (r *grainRef) Address() string {
+	return "local://" + r.id
+}
@@ -0,0 +1,269 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyStore 可以模拟写入失败的状态存储
+type flakyStore struct {
+	*MemoryGrainStore
+	fail atomic.Bool
+}
+
+func (f *flakyStore) Save(ctx context.Context, kind, key string, data []byte) error {
+	if f.fail.Load() {
+		return errors.New("store down")
+	}
+	return f.MemoryGrainStore.Save(ctx, kind, key, data)
+}
+
+// counterGrain 计数的虚拟actor，"inc"加一，其他消息返回当前值
+func counterGrain(activations *atomic.Int32) func(string, *GrainState) (ContextHandler, error) {
+	return func(key string, st *GrainState) (ContextHandler, error) {
+		activations.Add(1)
+		var n int
+		if _, err := st.Get(&n); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, msg interface{}) (interface{}, error) {
+			if msg == "inc" {
+				n++
+				if err := st.Set(n); err != nil {
+					return nil, err
+				}
+			}
+			return n, nil
+		}, nil
+	}
+}
+
+// deactivated 判断kind类型的虚拟actor已全部停用
+func deactivated(sys *ActorSystem, kind string) func() bool {
+	return func() bool {
+		infos, _ := sys.List(ListOptions{Kind: kind})
+		return len(infos) == 0
+	}
+}
+
+func TestGrainStateAcrossActivations(t *testing.T) {
+	sys := NewActorSystem()
+	store := &flakyStore{MemoryGrainStore: NewMemoryGrainStore()}
+	var activations atomic.Int32
+	if err := sys.RegisterGrain(GrainConfig{
+		Kind:        "counter",
+		IdleTimeout: 100 * time.Millisecond,
+		Store:       store,
+		Factory:     counterGrain(&activations),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := testContext(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := sys.Grain("counter", "a").Request(ctx, "inc", new(int)); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := activations.Load(); n != 1 {
+		t.Fatalf("%d concurrent activations, want 1", n)
+	}
+	var n int
+	if err := sys.Grain("counter", "a").Request(ctx, "get", &n); err != nil || n != 50 {
+		t.Fatal(n, err)
+	}
+	eventually(t, "grain deactivated", deactivated(sys, "counter"))
+
+	// 远程调用方按actor id访问时同样重新激活
+	_, addr := startServer(t, sys)
+	ref := dial(t, GrainID("counter", "a"), addr)
+	var f float64
+	if err := ref.Request(ctx, "inc", &f); err != nil || f != 51 {
+		t.Fatal(f, err)
+	}
+
+	// 写入失败时状态保留在内存中，下次激活继续使用
+	store.fail.Store(true)
+	eventually(t, "grain deactivated with failing store", deactivated(sys, "counter"))
+	store.fail.Store(false)
+	if err := sys.Grain("counter", "a").Request(ctx, "inc", &n); err != nil || n != 52 {
+		t.Fatal(n, err)
+	}
+
+	// 在停用与重新激活之间持续发送，不丢失更新
+	for i := 0; i < 20; i++ {
+		if err := sys.Grain("counter", "a").Request(ctx, "inc", &n); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(15 * time.Millisecond)
+	}
+	sys.Shutdown()
+	if data, _ := store.Load(ctx, "counter", "a"); string(data) != "72" {
+		t.Fatalf("stored %q, want 72", data)
+	}
+}
+
+func TestGrainDeactivateWhileHandlerSends(t *testing.T) {
+	sys := NewActorSystem()
+	if _, err := sys.RegisterActor("other", echo); err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	sent := make(chan error, 1)
+	if err := sys.RegisterGrain(GrainConfig{
+		Kind:        "worker",
+		IdleTimeout: 50 * time.Millisecond,
+		Factory: func(key string, st *GrainState) (ContextHandler, error) {
+			return func(ctx context.Context, msg interface{}) (interface{}, error) {
+				if msg == "block" {
+					started <- struct{}{}
+					<-release
+					// 停用期间处理函数仍可以向其他actor发送
+					sent <- sys.SendMessage("other", Message{Payload: "bye"})
+				}
+				return nil, nil
+			}, nil
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sys.Grain("worker", "w").Tell("block"); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	eventually(t, "grain deactivating", func() bool {
+		_, err := sys.actorFor("other") // 不应被停用中的grain阻塞
+		if err != nil {
+			return false
+		}
+		infos, _ := sys.List(ListOptions{Kind: "worker"})
+		return len(infos) == 0
+	})
+	close(release)
+
+	select {
+	case err := <-sent:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("grain deactivation deadlocked with a handler sending messages")
+	}
+	// 停用完成后透明地重新激活
+	if err := sys.Grain("worker", "w").Request(testContext(t), "ping", new(int)); err != nil {
+		t.Fatal(err)
+	}
+	sys.Shutdown()
+}
+
+func TestGrainLookupBeforeActivation(t *testing.T) {
+	sys := NewActorSystem()
+	var activations atomic.Int32
+	if err := sys.RegisterGrain(GrainConfig{Kind: "counter", Factory: counterGrain(&activations)}); err != nil {
+		t.Fatal(err)
+	}
+	_, addr := startServer(t, sys)
+	client := NewActorSystem(WithResolver(NewStaticResolver().Add(GrainID("counter", "*"), addr)))
+	defer client.Shutdown()
+	ctx := testContext(t)
+
+	// 未激活的虚拟actor在逻辑上始终存在
+	tests := []struct {
+		name   string
+		lookup func(id string) (ActorRef, error)
+	}{
+		{"local lookup", sys.Lookup},
+		{"remote lookup", client.Lookup},
+		{"resolve remote", func(id string) (ActorRef, error) {
+			ref, err := ResolveRemote(ctx, addr, id)
+			if err == nil {
+				t.Cleanup(func() { ref.(*remoteActorRef).Close() })
+			}
+			return ref, err
+		}},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := GrainID("counter", string(rune('a'+i)))
+			ref, err := tt.lookup(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var n int
+			if err := ref.Request(ctx, "inc", &n); err != nil || n != 1 {
+				t.Fatal(n, err)
+			}
+		})
+	}
+	if n := activations.Load(); n != int32(len(tests)) {
+		t.Fatalf("%d activations, want %d", n, len(tests))
+	}
+	if _, err := sys.Lookup("other/1"); !errors.Is(err, ErrActorNotFound) {
+		t.Fatalf("unknown id: %v", err)
+	}
+}
+
+func TestGrainRedeliversTellsQueuedAtDeactivation(t *testing.T) {
+	sys := NewActorSystem()
+	defer sys.Shutdown()
+	var dead atomic.Int32
+	sys.EventStream().Subscribe(func(e interface{}) {
+		if _, ok := e.(DeadLetter); ok {
+			dead.Add(1)
+		}
+	})
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	var activations, received atomic.Int32
+	if err := sys.RegisterGrain(GrainConfig{
+		Kind:        "inbox",
+		IdleTimeout: 50 * time.Millisecond,
+		Factory: func(key string, st *GrainState) (ContextHandler, error) {
+			activations.Add(1)
+			return func(ctx context.Context, msg interface{}) (interface{}, error) {
+				if msg == "block" {
+					started <- struct{}{}
+					<-release
+					return nil, nil
+				}
+				received.Add(1)
+				return nil, nil
+			}, nil
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	ref := sys.Grain("inbox", "a")
+	if err := ref.Tell("block"); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	// 这些消息排在阻塞的处理函数之后，停用时仍在邮箱中
+	const queued = 10
+	for i := 0; i < queued; i++ {
+		if err := ref.Tell(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	eventually(t, "grain deactivating", deactivated(sys, "inbox"))
+	close(release)
+
+	eventually(t, "queued tells redelivered", func() bool { return received.Load() == queued })
+	if n := activations.Load(); n != 2 {
+		t.Fatalf("%d activations, want 2", n)
+	}
+	if n := dead.Load(); n != 0 {
+		t.Fatalf("%d dead letters", n)
+	}
+}
@@ -0,0 +1,92 @@
+package actor
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ErrPeerDead 对端在多个心跳周期内没有响应
+var ErrPeerDead = errors.New("peer is dead")
+
+// DefaultHeartbeatMaxMissed 默认允许丢失的心跳次数
+const DefaultHeartbeatMaxMissed = 3
+
+// heartbeat 跟踪连接的活跃状态，收到任意帧都视为一次活动
+type heartbeat struct {
+	interval  time.Duration
+	maxMissed int
+	lastSeen  atomic.Int64
+
+	// detector 非空且本连接为key的心跳来源时，以phi值代替丢失次数判定对端失效
+	detector *FailureDetector
+	key      string
+	source   uint64
+}
+
+func newHeartbeat(interval time.Duration, maxMissed int) *heartbeat {
+	if maxMissed <= 0 {
+		maxMissed = DefaultHeartbeatMaxMissed
+	}
+	h := &heartbeat{interval: interval, maxMissed: maxMissed}
+	h.touch()
+	return h
+}
+
+// touch 记录一次对端活动
+func (h *heartbeat) touch() {
+	h.lastSeen.Store(time.Now().UnixNano())
+}
+
+// attach 使用共享的失效检测器，本连接接管key的心跳来源
+func (h *heartbeat) attach(detector *FailureDetector, key string) {
+	if detector == nil {
+		return
+	}
+	h.detector = detector
+	h.key = key
+	h.source = detector.attach(key)
+}
+
+// detach 连接关闭时移除本连接在失效检测器中的状态
+func (h *heartbeat) detach() {
+	if h.detector != nil {
+		h.detector.detach(h.key, h.source)
+	}
+}
+
+// beat 记录本端ping的pong到达，间隔由本端的心跳周期决定，用于驱动失效检测器
+func (h *heartbeat) beat() {
+	h.touch()
+	if h.detector != nil {
+		h.detector.heartbeatFrom(h.key, h.source)
+	}
+}
+
+// expired 判断对端是否已失效，同一节点已由更新的连接接管检测时按丢失次数判定
+func (h *heartbeat) expired() bool {
+	if h.detector != nil && h.detector.owns(h.key, h.source) {
+		return !h.detector.IsAvailable(h.key)
+	}
+	timeout := h.interval * time.Duration(h.maxMissed)
+	return time.Since(time.Unix(0, h.lastSeen.Load())) > timeout
+}
+
+// run 按周期发送ping，对端失效时调用dead
+func (h *heartbeat) run(done <-chan struct{}, ping func(), dead func()) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if h.expired() {
+				dead()
+				return
+			}
+			ping()
+		}
+	}
+}
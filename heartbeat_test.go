@@ -0,0 +1,149 @@
+package actor
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHeartbeatDetectsDeadPeer(t *testing.T) {
+	tests := []struct {
+		name string
+		opts func() []RemoteOption
+	}{
+		{"missed heartbeats", func() []RemoteOption {
+			return []RemoteOption{WithHeartbeat(20*time.Millisecond, 3)}
+		}},
+		{"phi accrual", func() []RemoteOption {
+			return []RemoteOption{WithHeartbeat(20*time.Millisecond, 3), WithFailureDetector(fastDetector())}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sys := NewActorSystem()
+			block := make(chan struct{})
+			defer close(block)
+			_, _ = sys.RegisterActor("slow", func(msg interface{}) (interface{}, error) {
+				<-block
+				return nil, nil
+			})
+			_, addr := startServer(t, sys)
+			proxy, freeze := freezingProxy(t, addr)
+			ref := dial(t, "slow", proxy, tt.opts()...)
+
+			time.Sleep(100 * time.Millisecond)
+			freeze()
+			err := ref.Request(testContext(t), 1, nil)
+			if !errors.Is(err, ErrPeerDead) {
+				t.Fatalf("got %v, want ErrPeerDead", err)
+			}
+		})
+	}
+}
+
+func TestHeartbeatDetectsPeerSilentBeforeFirstPong(t *testing.T) {
+	sys := NewActorSystem()
+	_, _ = sys.RegisterActor("echo", echo)
+	_, addr := startServer(t, sys)
+	proxy, freeze := freezingProxy(t, addr)
+	ref := dial(t, "echo", proxy, WithHeartbeat(20*time.Millisecond, 3), WithFailureDetector(fastDetector()))
+
+	// 握手完成后立即冻结，失效检测器尚未收到任何pong
+	freeze()
+	if err := ref.Request(testContext(t), 1, new(int)); !errors.Is(err, ErrPeerDead) {
+		t.Fatalf("got %v, want ErrPeerDead", err)
+	}
+}
+
+func TestHeartbeatKeepsIdleLinkAlive(t *testing.T) {
+	sys := NewActorSystem()
+	_, _ = sys.RegisterActor("echo", echo)
+	_, addr := startServer(t, sys, WithServerHeartbeat(20*time.Millisecond, 3))
+	ref := dial(t, "echo", addr, WithHeartbeat(20*time.Millisecond, 3))
+	time.Sleep(200 * time.Millisecond)
+	var out string
+	if err := ref.Request(testContext(t), "ok", &out); err != nil || out != "ok" {
+		t.Fatalf("idle link dropped: %v", err)
+	}
+}
+
+// stallingProxy 转发到target，调用stall后不再读取服务端数据，使服务端的写操作阻塞
+func stallingProxy(t *testing.T, target string) (addr string, stall func()) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stalled := make(chan struct{})
+	closed := make(chan struct{})
+	t.Cleanup(func() {
+		close(closed)
+		ln.Close()
+	})
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		u, err := net.Dial("tcp", target)
+		if err != nil {
+			return
+		}
+		defer u.Close()
+		go func() {
+			buf := make([]byte, 4096)
+			for {
+				select {
+				case <-stalled:
+					<-closed
+					return
+				default:
+				}
+				n, err := u.Read(buf)
+				if err != nil {
+					return
+				}
+				_, _ = c.Write(buf[:n])
+			}
+		}()
+		buf := make([]byte, 4096)
+		for {
+			n, err := c.Read(buf)
+			if err != nil {
+				return
+			}
+			_, _ = u.Write(buf[:n])
+		}
+	}()
+	var once sync.Once
+	return ln.Addr().String(), func() { once.Do(func() { close(stalled) }) }
+}
+
+func TestServerHeartbeatWithFullWriteQueue(t *testing.T) {
+	sys := NewActorSystem()
+	_, _ = sys.RegisterActor("echo", echo)
+	srv, addr := startServer(t, sys, WithServerHeartbeat(100*time.Millisecond, 3), WithServerWriteQueue(1, 0))
+	proxy, stall := stallingProxy(t, addr)
+	ref := dial(t, "echo", proxy)
+	if err := ref.Request(testContext(t), "ok", new(string)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 客户端不再读取后，大量响应占满套接字缓冲与写队列
+	stall()
+	ctx := testContext(t)
+	payload := strings.Repeat("x", 256<<10)
+	for i := 0; i < 64; i++ {
+		go func() {
+			_ = ref.Request(ctx, payload, new(string))
+		}()
+	}
+	eventually(t, "half-open connection closed by heartbeat", func() bool {
+		srv.activeMu.Lock()
+		defer srv.activeMu.Unlock()
+		return len(srv.active) == 0
+	})
+}
@@ -0,0 +1,287 @@
+package actor
+
+import (
+	"crypto/tls"
+	"time"
+)
+
+// SystemOption 配置ActorSystem
+type SystemOption func(*systemOptions)
+
+type systemOptions struct {
+	resolver      Resolver // 为nil时Lookup只查找本地actor
+	remoteOptions []RemoteOption
+}
+
+// WithResolver 设置Lookup在本地找不到actor时使用的解析器
+func WithResolver(r Resolver) SystemOption {
+	return func(o *systemOptions) {
+		o.resolver = r
+	}
+}
+
+// WithLookupOptions 设置Lookup连接远程actor时使用的选项
+func WithLookupOptions(opts ...RemoteOption) SystemOption {
+	return func(o *systemOptions) {
+		o.remoteOptions = opts
+	}
+}
+
+// ServerOption 配置ActorServer
+type ServerOption func(*serverOptions)
+
+type serverOptions struct {
+	codecs []string // 允许协商的编解码器，空表示接受所有已注册的编解码器
+	nodeID string
+	tls    *tls.Config
+
+	authenticator Authenticator
+	authorizer    Authorizer
+
+	compressions         []string // 允许协商的压缩算法
+	compressionThreshold int
+
+	heartbeatInterval  time.Duration // 为0时不主动发送心跳
+	heartbeatMaxMissed int
+	failureDetector    *FailureDetector
+
+	maxInFlightPerConn int // 单个连接同时处理中的请求上限，0表示不限制
+
+	writeQueueSize int
+	writeTimeout   time.Duration
+
+	dedupWindow int // 至少一次投递的去重窗口，0表示不去重
+
+	credits int // 每个连接的消息额度，0表示不做流控
+
+	spawnPolicy SpawnPolicy // 为nil时不允许远程创建actor
+}
+
+func defaultServerOptions() serverOptions {
+	return serverOptions{
+		nodeID:               newNodeID(),
+		compressions:         []string{CompressionGzip},
+		compressionThreshold: DefaultCompressionThreshold,
+		writeQueueSize:       defaultWriteQueueSize,
+		writeTimeout:         defaultWriteTimeout,
+		credits:              DefaultConnectionCredits,
+	}
+}
+
+// WithServerCodecs 限制服务端可协商的编解码器
+func WithServerCodecs(names ...string) ServerOption {
+	return func(o *serverOptions) {
+		o.codecs = names
+	}
+}
+
+// WithServerNodeID 设置服务端在握手中声明的节点标识
+func WithServerNodeID(id string) ServerOption {
+	return func(o *serverOptions) {
+		o.nodeID = id
+	}
+}
+
+// WithServerTLS 为服务端启用TLS
+// 需要校验客户端证书时设置 cfg.ClientAuth = tls.RequireAndVerifyClientCert 与 cfg.ClientCAs
+func WithServerTLS(cfg *tls.Config) ServerOption {
+	return func(o *serverOptions) {
+		o.tls = cfg
+	}
+}
+
+// WithAuthenticator 要求客户端在握手阶段通过认证
+func WithAuthenticator(a Authenticator) ServerOption {
+	return func(o *serverOptions) {
+		o.authenticator = a
+	}
+}
+
+// WithAuthorizer 对每条消息按调用方身份与目标actor进行授权
+func WithAuthorizer(a Authorizer) ServerOption {
+	return func(o *serverOptions) {
+		o.authorizer = a
+	}
+}
+
+// WithServerCompression 设置服务端允许协商的压缩算法与压缩阈值
+func WithServerCompression(threshold int, names ...string) ServerOption {
+	return func(o *serverOptions) {
+		o.compressionThreshold = threshold
+		o.compressions = names
+	}
+}
+
+// WithServerHeartbeat 按interval向客户端发送心跳，连续maxMissed个周期无响应时断开连接
+func WithServerHeartbeat(interval time.Duration, maxMissed int) ServerOption {
+	return func(o *serverOptions) {
+		o.heartbeatInterval = interval
+		o.heartbeatMaxMissed = maxMissed
+	}
+}
+
+// WithServerFailureDetector 使用phi-accrual检测器判定客户端失效，节点按握手声明的节点标识区分
+func WithServerFailureDetector(fd *FailureDetector) ServerOption {
+	return func(o *serverOptions) {
+		o.failureDetector = fd
+	}
+}
+
+// WithMaxInFlightPerConn 限制单个连接同时等待actor响应的请求数
+func WithMaxInFlightPerConn(n int) ServerOption {
+	return func(o *serverOptions) {
+		o.maxInFlightPerConn = n
+	}
+}
+
+// WithServerWriteQueue 设置每个连接的回复队列长度与写超时
+func WithServerWriteQueue(size int, timeout time.Duration) ServerOption {
+	return func(o *serverOptions) {
+		o.writeQueueSize = size
+		o.writeTimeout = timeout
+	}
+}
+
+// WithDeduplication 按(发送方, 序号)对至少一次投递的消息去重，
+// window为每个发送方在连续投递完成的序号之上最多记录的序号数
+func WithDeduplication(window int) ServerOption {
+	return func(o *serverOptions) {
+		o.dedupWindow = window
+	}
+}
+
+// WithConnectionCredits 设置每个连接可同时未处理完的消息数，
+// 客户端用尽额度后等待服务端归还，0表示不做流控
+func WithConnectionCredits(n int) ServerOption {
+	return func(o *serverOptions) {
+		o.credits = n
+	}
+}
+
+// WithSpawnPolicy 允许客户端按 SpawnPolicy 远程创建通过 RegisterKind 注册的actor类型
+func WithSpawnPolicy(p SpawnPolicy) ServerOption {
+	return func(o *serverOptions) {
+		o.spawnPolicy = p
+	}
+}
+
+// RemoteOption 配置远程actor引用
+type RemoteOption func(*remoteOptions)
+
+type remoteOptions struct {
+	codecs      []string // 按偏好顺序提供给服务端的编解码器
+	dialTimeout time.Duration
+	nodeID      string
+	tls         *tls.Config
+	credentials Credentials
+
+	compressions         []string // 按偏好顺序提供的压缩算法，为空时不压缩
+	compressionThreshold int
+
+	heartbeatInterval  time.Duration // 为0时不主动发送心跳
+	heartbeatMaxMissed int
+	failureDetector    *FailureDetector
+
+	writeQueueSize int
+	writeTimeout   time.Duration
+	sendTimeout    time.Duration // Tell等待发送队列与额度的最长时间
+	streamWindow   int           // 流式响应的接收窗口
+}
+
+func defaultRemoteOptions() remoteOptions {
+	return remoteOptions{
+		codecs:         []string{CodecJSON},
+		dialTimeout:    time.Second * 5,
+		nodeID:         newNodeID(),
+		writeQueueSize: defaultWriteQueueSize,
+		writeTimeout:   defaultWriteTimeout,
+		sendTimeout:    time.Second * 5,
+		streamWindow:   DefaultStreamWindow,
+
+		compressionThreshold: DefaultCompressionThreshold,
+	}
+}
+
+// WithCodecs 设置按偏好顺序提供的编解码器
+func WithCodecs(names ...string) RemoteOption {
+	return func(o *remoteOptions) {
+		o.codecs = names
+	}
+}
+
+// WithDialTimeout 设置连接超时时间
+func WithDialTimeout(d time.Duration) RemoteOption {
+	return func(o *remoteOptions) {
+		o.dialTimeout = d
+	}
+}
+
+// WithNodeID 设置客户端在握手中声明的节点标识
+func WithNodeID(id string) RemoteOption {
+	return func(o *remoteOptions) {
+		o.nodeID = id
+	}
+}
+
+// WithTLS 使用TLS连接服务端，双向认证时在cfg.Certificates中提供客户端证书
+func WithTLS(cfg *tls.Config) RemoteOption {
+	return func(o *remoteOptions) {
+		o.tls = cfg
+	}
+}
+
+// WithCredentials 设置握手阶段使用的认证凭证
+func WithCredentials(c Credentials) RemoteOption {
+	return func(o *remoteOptions) {
+		o.credentials = c
+	}
+}
+
+// WithCompression 设置按偏好顺序提供的压缩算法与压缩阈值，小于阈值的帧不压缩
+func WithCompression(threshold int, names ...string) RemoteOption {
+	return func(o *remoteOptions) {
+		o.compressionThreshold = threshold
+		o.compressions = names
+	}
+}
+
+// WithHeartbeat 按interval向服务端发送心跳，连续maxMissed个周期无响应时判定对端失效，
+// 连接被关闭且所有等待中的请求返回 ErrPeerDead
+func WithHeartbeat(interval time.Duration, maxMissed int) RemoteOption {
+	return func(o *remoteOptions) {
+		o.heartbeatInterval = interval
+		o.heartbeatMaxMissed = maxMissed
+	}
+}
+
+// WithFailureDetector 使用phi-accrual检测器判定服务端失效，节点按连接地址区分，
+// 同一地址的多个连接中由最新建立的连接驱动检测，需同时通过 WithHeartbeat 启用心跳
+func WithFailureDetector(fd *FailureDetector) RemoteOption {
+	return func(o *remoteOptions) {
+		o.failureDetector = fd
+	}
+}
+
+// WithWriteQueue 设置发送队列长度与写超时
+func WithWriteQueue(size int, timeout time.Duration) RemoteOption {
+	return func(o *remoteOptions) {
+		o.writeQueueSize = size
+		o.writeTimeout = timeout
+	}
+}
+
+// WithSendTimeout 设置Tell在发送队列已满或额度不足时的最长等待时间，超时返回 ErrBackpressure
+func WithSendTimeout(d time.Duration) RemoteOption {
+	return func(o *remoteOptions) {
+		o.sendTimeout = d
+	}
+}
+
+// WithStreamWindow 设置流式响应的接收窗口，即服务端在等待消费前最多发送的分块数
+func WithStreamWindow(n int) RemoteOption {
+	return func(o *remoteOptions) {
+		if n > 0 {
+			o.streamWindow = n
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package actor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+)
+
+// Peer 描述远程连接的对端信息
+type Peer struct {
+	Addr   net.Addr // 对端网络地址
+	NodeID string   // 对端在握手中声明的节点标识
+
+	// Certificates 已验证的对端证书链，叶子证书在前；
+	// 未启用TLS、对端未提供证书或证书未经验证(如 tls.RequestClientCert)时为空
+	Certificates []*x509.Certificate
+
+	// Principal 服务端启用认证时的调用方身份
+	Principal *Principal
+}
+
+// Verified 对端是否提供了经过验证的证书
+func (p *Peer) Verified() bool {
+	return len(p.Certificates) > 0
+}
+
+// CommonName 返回对端证书的CN，未验证时返回空字符串
+func (p *Peer) CommonName() string {
+	if !p.Verified() {
+		return ""
+	}
+	return p.Certificates[0].Subject.CommonName
+}
+
+type peerKey struct{}
+
+// withPeer 将对端信息写入上下文
+func withPeer(ctx context.Context, p *Peer) context.Context {
+	return context.WithValue(ctx, peerKey{}, p)
+}
+
+// PeerFromContext 获取消息来源的对端信息，仅对经ActorServer投递的消息有效
+func PeerFromContext(ctx context.Context) (*Peer, bool) {
+	p, ok := ctx.Value(peerKey{}).(*Peer)
+	return p, ok
+}
+
+// newPeer 根据连接构造对端信息
+func newPeer(conn net.Conn) *Peer {
+	p := &Peer{
+		Addr: conn.RemoteAddr(),
+	}
+	if tc, ok := conn.(*tls.Conn); ok {
+		// PeerCertificates 包含对端发送的任意证书，只有 VerifiedChains 中的证书链经过验证
+		if chains := tc.ConnectionState().VerifiedChains; len(chains) > 0 {
+			p.Certificates = chains[0]
+		}
+	}
+	return p
+}
@@ -0,0 +1,102 @@
+package actor
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	typesMu     sync.RWMutex
+	typesByName = make(map[string]reflect.Type)
+	namesByType = make(map[reflect.Type]string)
+)
+
+// RegisterMessageType 注册一个跨网络传输的消息类型
+// 已注册类型的消息在远端会被解码为同一具体类型，而不是map[string]interface{}
+func RegisterMessageType[T any](name string) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	typesMu.Lock()
+	defer typesMu.Unlock()
+	if old, ok := typesByName[name]; ok && old != t {
+		panic(fmt.Sprintf("actor: message type name %q already registered for %v", name, old))
+	}
+	typesByName[name] = t
+	namesByType[t] = name
+}
+
+// lookupTypeName 获取值对应的注册名称
+func lookupTypeName(v interface{}) (string, bool) {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+	name, ok := namesByType[reflect.TypeOf(v)]
+	return name, ok
+}
+
+// lookupType 获取注册名称对应的类型
+func lookupType(name string) (reflect.Type, bool) {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+	t, ok := typesByName[name]
+	return t, ok
+}
+
+// anyPayload 包装未注册类型的负载
+type anyPayload struct {
+	Value interface{}
+}
+
+// encodePayload 编码负载，返回类型名称与负载字节
+func encodePayload(codec Codec, v interface{}) (string, []byte, error) {
+	if v == nil {
+		return "", nil, nil
+	}
+	if name, ok := lookupTypeName(v); ok {
+		data, err := codec.Marshal(v)
+		return name, data, err
+	}
+	data, err := codec.Marshal(&anyPayload{Value: v})
+	return "", data, err
+}
+
+// decodePayload 按类型名称解码负载，未注册的名称解码为通用类型
+func decodePayload(codec Codec, name string, data []byte) (interface{}, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	if name != "" {
+		t, ok := lookupType(name)
+		if !ok {
+			return nil, fmt.Errorf("unregistered message type %q", name)
+		}
+		ptr := reflect.New(t)
+		if err := codec.Unmarshal(data, ptr.Interface()); err != nil {
+			return nil, err
+		}
+		return ptr.Elem().Interface(), nil
+	}
+	var p anyPayload
+	if err := codec.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	return p.Value, nil
+}
+
+// newRemoteMessage 构造携带类型信息的远程消息
+func newRemoteMessage(codec Codec, target string, payload interface{}) (*remoteMessage, error) {
+	name, data, err := encodePayload(codec, payload)
+	if err != nil {
+		return nil, err
+	}
+	return &remoteMessage{
+		Target:      target,
+		PayloadType: name,
+		Payload:     data,
+	}, nil
+}
+
+// decode 解码远程消息的负载
+func (m *remoteMessage) decode(codec Codec) (interface{}, error) {
+	return decodePayload(codec, m.PayloadType, m.Payload)
+}
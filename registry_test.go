@@ -0,0 +1,92 @@
+package actor
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testOrder 仅在测试中注册的消息类型
+type testOrder struct {
+	ID    int64
+	Items []string
+}
+
+func init() {
+	RegisterMessageType[testOrder]("actor.testOrder")
+}
+
+func TestRegisterMessageTypeConflict(t *testing.T) {
+	RegisterMessageType[testOrder]("actor.testOrder") // 重复注册同一类型是允许的
+	defer func() {
+		if recover() == nil {
+			t.Fatal("registering a different type under a taken name did not panic")
+		}
+	}()
+	RegisterMessageType[int64]("actor.testOrder")
+}
+
+func TestPayloadTypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  interface{}
+		typeName string
+		want     interface{}
+	}{
+		{"registered", testOrder{ID: 1, Items: []string{"x"}}, "actor.testOrder", testOrder{ID: 1, Items: []string{"x"}}},
+		{"builtin registered", ListOptions{Prefix: "a"}, "actor.ListOptions", ListOptions{Prefix: "a"}},
+		{"unregistered string", "hi", "", "hi"},
+		{"nil", nil, "", nil},
+	}
+	for _, codec := range []string{CodecJSON, CodecGob} {
+		c, _ := GetCodec(codec)
+		for _, tt := range tests {
+			t.Run(codec+"/"+tt.name, func(t *testing.T) {
+				m, err := newRemoteMessage(c, "a", tt.payload)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if m.PayloadType != tt.typeName {
+					t.Fatalf("type name %q, want %q", m.PayloadType, tt.typeName)
+				}
+				got, err := m.decode(c)
+				if err != nil || !reflect.DeepEqual(got, tt.want) {
+					t.Fatalf("decoded %#v %v, want %#v", got, err, tt.want)
+				}
+			})
+		}
+	}
+
+	c, _ := GetCodec(CodecJSON)
+	if _, err := decodePayload(c, "actor.unknown", []byte("{}")); err == nil || !strings.Contains(err.Error(), "unregistered") {
+		t.Fatalf("decoded an unknown type name: %v", err)
+	}
+}
+
+func TestRemotePayloadKeepsType(t *testing.T) {
+	sys := NewActorSystem()
+	if _, err := sys.RegisterActor("orders", func(msg interface{}) (interface{}, error) {
+		o, ok := msg.(testOrder)
+		if !ok {
+			return nil, fmt.Errorf("got %T", msg)
+		}
+		o.ID++
+		return o, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	_, addr := startServer(t, sys)
+	ctx := testContext(t)
+
+	for _, codec := range []string{CodecJSON, CodecGob} {
+		ref := dial(t, "orders", addr, WithCodecs(codec))
+		var out testOrder
+		if err := ref.Request(ctx, testOrder{ID: 1, Items: []string{"x"}}, &out); err != nil || out.ID != 2 || out.Items[0] != "x" {
+			t.Fatalf("%s: %+v %v", codec, out, err)
+		}
+		if err := ref.Request(ctx, "not an order", new(testOrder)); err == nil || !strings.Contains(err.Error(), "got string") {
+			t.Fatalf("%s: %v", codec, err)
+		}
+	}
+}
@@ -3,7 +3,8 @@ package actor
 import (
     "bufio"
     "context"
-    "encoding/json"
+    "crypto/tls"
+    "errors"
     "fmt"
     "net"
     "sync"
@@ -13,83 +14,184 @@ import (
 
 // remoteActorRef 表示远程actor的引用
 type remoteActorRef struct {
-    id        string
-    address   string
-    conn      net.Conn
-    mu        sync.RWMutex
-    msgId     atomic.Int64
-    pending   map[int64]chan Response
-    pendingMu sync.RWMutex
-    writeCh   chan interface{}
+    id           string
+    address      string
+    conn         net.Conn
+    mu           sync.RWMutex
+    msgId        atomic.Int64
+    pending      map[int64]chan Response
+    streams      map[int64]*Stream
+    uploads      map[int64]*remoteUploadStream
+    pendingMu    sync.RWMutex
+    out          *frameWriter
+    reader       *bufio.Reader
+    wire         *wireConn
+    stats        linkStats
+    peerNode     string
+    hb           *heartbeat
+    flow         *flowControl
+    sendTimeout  time.Duration
+    streamWindow int
+    closed       chan struct{}
+    closeOnce    sync.Once
+}
+
+// RemoteActorRef 远程actor引用，NewRemoteActorRef 返回的引用实现了该接口
+type RemoteActorRef interface {
+    ActorRef
+    
+    // Stats 获取连接的流量统计
+    Stats() LinkStats
+    
+    // Close 关闭连接，等待中的请求返回 ErrConnectionClosed
+    Close() error
+    
+    // Credits 返回服务端授予的剩余消息额度，服务端未启用流控时返回-1
+    Credits() int64
 }
 
 // NewRemoteActorRef 创建一个远程actor引用
-func NewRemoteActorRef(id string, address string) (ActorRef, error) {
-    conn, err := net.DialTimeout("tcp", address, time.Second*5)
+func NewRemoteActorRef(id string, address string, opts ...RemoteOption) (ActorRef, error) {
+    o := defaultRemoteOptions()
+    for _, opt := range opts {
+        opt(&o)
+    }
+    
+    var conn net.Conn
+    var err error
+    if o.tls != nil {
+        dialer := &net.Dialer{Timeout: o.dialTimeout}
+        conn, err = tls.DialWithDialer(dialer, "tcp", address, o.tls)
+    } else {
+        conn, err = net.DialTimeout("tcp", address, o.dialTimeout)
+    }
     if err != nil {
         return nil, fmt.Errorf("connect failed: %w", err)
     }
     
+    reader := bufio.NewReaderSize(conn, 32*1024) // 32KB buffer
+    writer := bufio.NewWriterSize(conn, 32*1024)
+    _ = conn.SetDeadline(time.Now().Add(handshakeTimeout))
+    hs, err := clientHandshake(reader, writer, &o)
+    if err != nil {
+        _ = conn.Close()
+        return nil, err
+    }
+    _ = conn.SetDeadline(time.Time{})
+    
     ref := &remoteActorRef{
-        id:      id,
-        address: address,
-        conn:    conn,
-        pending: make(map[int64]chan Response),
-        writeCh: make(chan interface{}, 100), // 添加缓冲区以避免阻塞
+        id:       id,
+        address:  address,
+        conn:     conn,
+        pending:  make(map[int64]chan Response),
+        streams:  make(map[int64]*Stream),
+        uploads:  make(map[int64]*remoteUploadStream),
+        reader:   reader,
+        peerNode: hs.peerNodeID,
+        hb:       newHeartbeat(o.heartbeatInterval, o.heartbeatMaxMissed),
+        flow:     newFlowControl(hs.credits),
+        closed:   make(chan struct{}),
+    }
+    ref.sendTimeout = o.sendTimeout
+    ref.streamWindow = o.streamWindow
+    ref.out = newFrameWriter(conn, writer, o.writeQueueSize, o.writeTimeout, func(err error) {
+        ref.handleError(fmt.Errorf("write failed: %w", err))
+    })
+    ref.hb.attach(o.failureDetector, address)
+    ref.wire = &wireConn{
+        codec:      hs.codec,
+        compressor: hs.compressor,
+        threshold:  o.compressionThreshold,
+        stats:      &ref.stats,
     }
     
     go ref.readLoop()
-    go ref.writeLoop()
+    go ref.out.run()
+    if o.heartbeatInterval > 0 {
+        go ref.hb.run(ref.closed, func() {
+            ref.sendControl(frame{Type: framePing})
+        }, func() {
+            ref.handleError(ErrPeerDead)
+        })
+    }
     
     return ref, nil
 }
 
 // readLoop 持续读取响应
 func (r *remoteActorRef) readLoop() {
-    defer r.cleanup()
-    reader := bufio.NewReaderSize(r.conn, 32*1024) // 32KB buffer
-    
-    dec := json.NewDecoder(reader)
     for {
-        var msg struct {
-            Id      int64       `json:"id"`
-            Error   string      `json:"error,omitempty"`
-            Payload interface{} `json:"payload,omitempty"`
-        }
-        
-        if err := dec.Decode(&msg); err != nil {
+        f, err := readFrame(r.reader)
+        if err != nil {
             r.handleError(fmt.Errorf("read failed: %w", err))
             return
         }
+        r.hb.touch()
         
-        r.pendingMu.RLock()
-        ch, ok := r.pending[msg.Id]
-        r.pendingMu.RUnlock()
+        switch f.Type {
+        case framePing:
+            r.sendControl(frame{Type: framePong, ID: f.ID})
+            continue
+        case framePong:
+            r.hb.beat()
+            continue
+        case frameCredit:
+            r.flow.grant(int64(f.ID))
+            continue
+        case frameStreamChunk, frameStreamEnd:
+            r.handleStreamFrame(f)
+            continue
+        case frameUploadCredit:
+            r.pendingMu.RLock()
+            u := r.uploads[int64(f.ID)]
+            r.pendingMu.RUnlock()
+            if u != nil {
+                u.flow.grant(int64(decodeCount(f.Payload)))
+            }
+            continue
+        case frameResponse:
+        default:
+            continue
+        }
+        
+        // 单帧解码失败只影响对应的请求
+        msg, payload, err := r.wire.decodeMessage(f)
+        if err != nil {
+            msg = &remoteMessage{Error: NewError(CodeInvalidMessage, fmt.Sprintf("decode response failed: %v", err))}
+        }
         
-        if ok {
-            ch <- Response{Data: msg.Payload, Error: msg.Error}
-            r.pendingMu.Lock()
-            delete(r.pending, msg.Id)
+        if ch, ok := r.takePending(int64(f.ID)); ok {
+            ch <- Response{Data: payload, Error: msg.Error}
             close(ch)
-            r.pendingMu.Unlock()
         }
+        r.takeUpload(int64(f.ID))
     }
 }
 
-// writeLoop 持续写入请求
-func (r *remoteActorRef) writeLoop() {
-    writer := bufio.NewWriterSize(r.conn, 32*1024)
-    enc := json.NewEncoder(writer)
-    for msg := range r.writeCh {
-        if err := enc.Encode(msg); err != nil {
-            r.handleError(fmt.Errorf("write failed: %w", err))
-            return
-        }
+// takePending 取出并移除等待中的请求
+func (r *remoteActorRef) takePending(id int64) (chan Response, bool) {
+    r.pendingMu.Lock()
+    defer r.pendingMu.Unlock()
+    ch, ok := r.pending[id]
+    if ok {
+        delete(r.pending, id)
     }
+    return ch, ok
+}
+
+// sendControl 发送控制帧，写队列已满时丢弃
+func (r *remoteActorRef) sendControl(f frame) {
+    r.out.tryEnqueue(f)
 }
 
-// cleanup 清理资源
-func (r *remoteActorRef) cleanup() {
+// cleanup 清理资源，reason为等待中的请求收到的错误
+func (r *remoteActorRef) cleanup(reason error) {
+    r.closeOnce.Do(func() {
+        close(r.closed)
+        r.out.close()
+        r.hb.detach()
+    })
+    
     r.mu.Lock()
     if r.conn != nil {
         _ = r.conn.Close()
@@ -100,93 +202,333 @@ func (r *remoteActorRef) cleanup() {
     // 通知所有等待的请求
     r.pendingMu.Lock()
     for id, ch := range r.pending {
-        ch <- Response{Error: "connection closed"}
+        ch <- Response{Error: toError(reason)}
         close(ch)
         delete(r.pending, id)
     }
+    for id, st := range r.streams {
+        st.end(reason)
+        delete(r.streams, id)
+    }
+    for id, u := range r.uploads {
+        u.finish()
+        delete(r.uploads, id)
+    }
     r.pendingMu.Unlock()
 }
 
-// handleError 处理错误
+// handleError 处理错误，只输出导致连接关闭的第一个错误
 func (r *remoteActorRef) handleError(err error) {
-    r.cleanup()
+    select {
+    case <-r.closed:
+        return
+    default:
+    }
+    if errors.Is(err, ErrPeerDead) {
+        r.cleanup(err)
+    } else {
+        r.cleanup(fmt.Errorf("%w: %w", ErrConnectionClosed, err))
+    }
     fmt.Println(err)
 }
 
 // Request 发送请求并等待响应
 func (r *remoteActorRef) Request(ctx context.Context, req interface{}, resp interface{}) error {
+    response, err := r.call(ctx, frameRequest, req, nil)
+    if err != nil {
+        return err
+    }
+    return assignResult(response.Data, resp)
+}
+
+// TellWithAck 发送单向消息并等待服务端的投递确认
+func (r *remoteActorRef) TellWithAck(ctx context.Context, msg interface{}) error {
+    _, err := r.call(ctx, frameTellAck, msg, nil)
+    return err
+}
+
+// tellSequenced 发送带发送方序号的确认消息，服务端据此去重
+func (r *remoteActorRef) tellSequenced(ctx context.Context, msg interface{}, sender string, seq uint64) error {
+    _, err := r.call(ctx, frameTellAck, msg, func(m *remoteMessage) {
+        m.Sender = sender
+        m.Seq = seq
+    })
+    return err
+}
+
+// call 发送需要响应的帧并等待对应的响应帧，decorate用于补充消息字段
+func (r *remoteActorRef) call(ctx context.Context, typ frameType, payload interface{}, decorate func(*remoteMessage)) (Response, error) {
     r.mu.RLock()
     conn := r.conn
     r.mu.RUnlock()
     
     if conn == nil {
-        return fmt.Errorf("connection is closed")
+        return Response{}, ErrConnectionClosed
     }
     
     msgID := r.msgId.Add(1)
     respCh := make(chan Response, 1)
     
     r.pendingMu.Lock()
+    select {
+    case <-r.closed:
+        r.pendingMu.Unlock()
+        return Response{}, ErrConnectionClosed
+    default:
+    }
     r.pending[msgID] = respCh
     r.pendingMu.Unlock()
     
-    msg := struct {
-        Id      int64       `json:"id"`
-        Target  string      `json:"target"`
-        Type    string      `json:"type"`
-        Payload interface{} `json:"payload"`
-    }{
-        Id:      msgID,
-        Target:  r.id,
-        Type:    "Request",
-        Payload: req,
+    m, err := newRemoteMessage(r.wire.codec, r.id, payload)
+    var f frame
+    if err == nil {
+        m.Timeout = remainingTimeout(ctx)
+        if decorate != nil {
+            decorate(m)
+        }
+        f, err = r.wire.encodeMessage(typ, uint64(msgID), m)
+    }
+    if err != nil {
+        r.takePending(msgID)
+        return Response{}, fmt.Errorf("encode request failed: %w", err)
     }
     
-    r.writeCh <- msg
+    if err := r.send(ctx, f); err != nil {
+        r.takePending(msgID)
+        return Response{}, err
+    }
     
     select {
     case <-ctx.Done():
-        r.pendingMu.Lock()
-        delete(r.pending, msgID)
-        r.pendingMu.Unlock()
-        return ctx.Err()
+        if _, ok := r.takePending(msgID); ok && typ == frameRequest {
+            // 通知服务端取消仍在处理的请求
+            r.sendControl(frame{Type: frameCancel, ID: uint64(msgID)})
+        }
+        return Response{}, ctx.Err()
     case response := <-respCh:
-        if response.Error != "" {
-            return fmt.Errorf(response.Error)
+        if response.Error != nil {
+            return response, response.Error
         }
-        if resp != nil {
-            data, err := json.Marshal(response.Data)
-            if err != nil {
-                return fmt.Errorf("marshal response failed: %w", err)
-            }
-            return json.Unmarshal(data, resp)
+        return response, nil
+    }
+}
+
+// RequestStream 发送流式请求，返回的Stream按接收窗口向服务端归还额度
+func (r *remoteActorRef) RequestStream(ctx context.Context, req interface{}) (*Stream, error) {
+    return r.requestStream(ctx, r.id, req)
+}
+
+// requestStream 向连接上的target发送流式请求
+func (r *remoteActorRef) requestStream(ctx context.Context, target string, req interface{}) (*Stream, error) {
+    msgID := r.msgId.Add(1)
+    window := r.streamWindow
+    st := newStream(ctx, window)
+    
+    r.pendingMu.Lock()
+    select {
+    case <-r.closed:
+        r.pendingMu.Unlock()
+        return nil, ErrConnectionClosed
+    default:
+    }
+    r.streams[msgID] = st
+    r.pendingMu.Unlock()
+    
+    // 每消费半个窗口归还一次额度
+    var consumed atomic.Int64
+    batch := int64(window / 2)
+    if batch < 1 {
+        batch = 1
+    }
+    st.onConsume = func() {
+        if n := consumed.Add(1); n >= batch {
+            consumed.Add(-n)
+            r.out.enqueue(frame{Type: frameStreamCredit, ID: uint64(msgID), Payload: encodeCount(uint32(n))})
+        }
+    }
+    // 调用方取消或提前关闭时通知服务端
+    go func() {
+        <-st.ctx.Done()
+        if _, ok := r.takeStream(msgID); ok {
+            r.sendControl(frame{Type: frameCancel, ID: uint64(msgID)})
+            st.end(st.ctx.Err())
         }
-        return nil
+    }()
+    
+    m, err := newRemoteMessage(r.wire.codec, target, req)
+    var f frame
+    if err == nil {
+        m.Timeout = remainingTimeout(ctx)
+        m.Window = int64(window)
+        f, err = r.wire.encodeMessage(frameStreamRequest, uint64(msgID), m)
+    }
+    if err == nil {
+        err = r.send(ctx, f)
+    }
+    if err != nil {
+        r.takeStream(msgID)
+        st.end(err)
+        return nil, err
+    }
+    return st, nil
+}
+
+// takeStream 取出并移除进行中的流
+func (r *remoteActorRef) takeStream(id int64) (*Stream, bool) {
+    r.pendingMu.Lock()
+    defer r.pendingMu.Unlock()
+    st, ok := r.streams[id]
+    if ok {
+        delete(r.streams, id)
+    }
+    return st, ok
+}
+
+// handleStreamFrame 将分块或结束帧交给对应的流
+func (r *remoteActorRef) handleStreamFrame(f frame) {
+    id := int64(f.ID)
+    msg, payload, err := r.wire.decodeMessage(f)
+    if f.Type == frameStreamEnd {
+        st, ok := r.takeStream(id)
+        if !ok {
+            return
+        }
+        if err != nil {
+            st.end(fmt.Errorf("decode response failed: %w", err))
+        } else if msg.Error != nil {
+            st.end(msg.Error)
+        } else {
+            st.end(nil)
+        }
+        return
+    }
+    
+    r.pendingMu.RLock()
+    st, ok := r.streams[id]
+    r.pendingMu.RUnlock()
+    if !ok {
+        return
+    }
+    if err != nil {
+        if _, ok := r.takeStream(id); ok {
+            r.sendControl(frame{Type: frameCancel, ID: f.ID})
+            st.end(fmt.Errorf("decode response failed: %w", err))
+        }
+        return
+    }
+    // 服务端遵守窗口额度时缓冲区不会溢出，溢出时终止流而不是丢弃分块
+    select {
+    case st.chunks <- payload:
+    default:
+        if _, ok := r.takeStream(id); ok {
+            r.sendControl(frame{Type: frameCancel, ID: f.ID})
+            st.end(ErrWindowExceeded)
+        }
+    }
+}
+
+// RequestUpload 打开上传流，分块按actor端的接收窗口发送，结束后等待一次响应
+func (r *remoteActorRef) RequestUpload(ctx context.Context) (UploadStream, error) {
+    return r.requestUpload(ctx, r.id)
+}
+
+// requestUpload 向连接上的target打开上传流
+func (r *remoteActorRef) requestUpload(ctx context.Context, target string) (UploadStream, error) {
+    msgID := r.msgId.Add(1)
+    u := &remoteUploadStream{
+        ref:      r,
+        id:       msgID,
+        ctx:      ctx,
+        flow:     newFlowControl(int64(r.streamWindow)),
+        reply:    make(chan Response, 1),
+        finished: make(chan struct{}),
+    }
+    
+    r.pendingMu.Lock()
+    select {
+    case <-r.closed:
+        r.pendingMu.Unlock()
+        return nil, ErrConnectionClosed
+    default:
+    }
+    r.pending[msgID] = u.reply
+    r.uploads[msgID] = u
+    r.pendingMu.Unlock()
+    
+    m := &remoteMessage{Target: target, Window: int64(r.streamWindow)}
+    m.Timeout = remainingTimeout(ctx)
+    f, err := r.wire.encodeMessage(frameUploadRequest, uint64(msgID), m)
+    if err == nil {
+        err = r.send(ctx, f)
+    }
+    if err != nil {
+        r.takePending(msgID)
+        r.takeUpload(msgID)
+        return nil, err
+    }
+    
+    // 调用方取消时通知服务端
+    go func() {
+        select {
+        case <-ctx.Done():
+            u.Cancel()
+        case <-u.finished:
+        }
+    }()
+    return u, nil
+}
+
+// takeUpload 移除进行中的上传流并唤醒阻塞的发送方
+func (r *remoteActorRef) takeUpload(id int64) {
+    r.pendingMu.Lock()
+    u, ok := r.uploads[id]
+    if ok {
+        delete(r.uploads, id)
+    }
+    r.pendingMu.Unlock()
+    if ok {
+        u.finish()
     }
 }
 
 // Tell 发送单向消息
 func (r *remoteActorRef) Tell(msg interface{}) error {
+    return r.tell(r.id, msg)
+}
+
+// tell 向连接上的target发送单向消息
+func (r *remoteActorRef) tell(target string, msg interface{}) error {
     r.mu.RLock()
     conn := r.conn
     r.mu.RUnlock()
     
     if conn == nil {
-        return fmt.Errorf("connection is closed")
+        return ErrConnectionClosed
     }
     
-    m := struct {
-        Target  string      `json:"target"`
-        Type    string      `json:"type"`
-        Payload interface{} `json:"payload"`
-    }{
-        Target:  r.id,
-        Type:    "Tell",
-        Payload: msg,
+    m, err := newRemoteMessage(r.wire.codec, target, msg)
+    var f frame
+    if err == nil {
+        f, err = r.wire.encodeMessage(frameTell, 0, m)
+    }
+    if err != nil {
+        return fmt.Errorf("encode message failed: %w", err)
     }
     
-    r.writeCh <- m
-    
+    ctx, cancel := context.WithTimeout(context.Background(), r.sendTimeout)
+    defer cancel()
+    return r.send(ctx, f)
+}
+
+// send 获取发送额度后将帧放入发送队列，ctx结束前无法发送时返回 ErrBackpressure
+func (r *remoteActorRef) send(ctx context.Context, f frame) error {
+    if err := r.flow.acquire(ctx, r.closed); err != nil {
+        return err
+    }
+    if err := r.out.enqueueContext(ctx, f); err != nil {
+        r.flow.grant(1)
+        return err
+    }
     return nil
 }
 
@@ -197,3 +539,29 @@ func (r *remoteActorRef) ID() string {
 func (r *remoteActorRef) Address() string {
     return r.address
 }
+
+func (r *remoteActorRef) Stats() LinkStats {
+    return r.stats.snapshot()
+}
+
+func (r *remoteActorRef) Credits() int64 {
+    return r.flow.remaining()
+}
+
+func (r *remoteActorRef) Close() error {
+    r.cleanup(ErrConnectionClosed)
+    return nil
+}
+
+// remainingTimeout 返回ctx剩余的时间(纳秒)，没有截止时间时返回0
+// 只传递剩余时长而不是截止时刻，避免两端时钟偏差影响超时
+func remainingTimeout(ctx context.Context) int64 {
+    deadline, ok := ctx.Deadline()
+    if !ok {
+        return 0
+    }
+    if d := time.Until(deadline); d > 0 {
+        return int64(d)
+    }
+    return 1 // 已到期，服务端收到后立即超时
+}
@@ -0,0 +1,214 @@
+package actor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// lookupTimeout Lookup解析与连接远程actor的最长时间
+const lookupTimeout = time.Second * 10
+
+// Resolver 将不在本地的actor id解析为可能承载它的服务端地址，按优先顺序返回
+type Resolver interface {
+	Resolve(ctx context.Context, id string) ([]string, error)
+}
+
+// ResolverFunc 函数形式的Resolver
+type ResolverFunc func(ctx context.Context, id string) ([]string, error)
+
+// Resolve 实现Resolver
+func (f ResolverFunc) Resolve(ctx context.Context, id string) ([]string, error) {
+	return f(ctx, id)
+}
+
+// ChainResolver 依次尝试多个解析器，返回第一个非空的结果
+type ChainResolver []Resolver
+
+// Resolve 实现Resolver
+func (c ChainResolver) Resolve(ctx context.Context, id string) ([]string, error) {
+	for _, r := range c {
+		addrs, err := r.Resolve(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if len(addrs) > 0 {
+			return addrs, nil
+		}
+	}
+	return nil, nil
+}
+
+// StaticResolver 静态地址表，id按 path.Match 通配符匹配，先添加的规则优先
+type StaticResolver struct {
+	mu     sync.RWMutex
+	routes []staticRoute
+}
+
+type staticRoute struct {
+	pattern   string
+	addresses []string
+}
+
+// NewStaticResolver 创建空的静态地址表
+func NewStaticResolver() *StaticResolver {
+	return &StaticResolver{}
+}
+
+// Add 将匹配pattern的actor指向addresses，返回自身以便链式调用
+func (s *StaticResolver) Add(pattern string, addresses ...string) *StaticResolver {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes = append(s.routes, staticRoute{pattern: pattern, addresses: addresses})
+	return s
+}
+
+// Resolve 实现Resolver
+func (s *StaticResolver) Resolve(_ context.Context, id string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, r := range s.routes {
+		if ok, _ := path.Match(r.pattern, id); ok {
+			return r.addresses, nil
+		}
+	}
+	return nil, nil
+}
+
+// SRVResolver 通过DNS SRV记录获取服务端地址，各地址是否承载该actor由Lookup逐个确认
+type SRVResolver struct {
+	Service string // 例如 "actor"
+	Proto   string // 例如 "tcp"
+	Name    string // 例如 "cluster.example.com"
+
+	// LookupSRV 为nil时使用 net.DefaultResolver，可替换为其他服务发现实现
+	LookupSRV func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// Resolve 实现Resolver，地址按SRV记录的优先级与权重排序
+func (r *SRVResolver) Resolve(ctx context.Context, _ string) ([]string, error) {
+	lookup := r.LookupSRV
+	if lookup == nil {
+		lookup = net.DefaultResolver.LookupSRV
+	}
+	_, records, err := lookup(ctx, r.Service, r.Proto, r.Name)
+	if err != nil {
+		return nil, fmt.Errorf("lookup srv failed: %w", err)
+	}
+	addrs := make([]string, 0, len(records))
+	for _, rec := range records {
+		host := strings.TrimSuffix(rec.Target, ".")
+		addrs = append(addrs, net.JoinHostPort(host, strconv.Itoa(int(rec.Port))))
+	}
+	return addrs, nil
+}
+
+// Registry 集群共享的actor位置表，节点登记自己承载的actor后其它节点即可解析
+type Registry struct {
+	mu      sync.RWMutex
+	entries map[string]string
+}
+
+// NewRegistry 创建空的位置表
+func NewRegistry() *Registry {
+	return &Registry{entries: make(map[string]string)}
+}
+
+// Register 登记actor所在的服务端地址
+func (r *Registry) Register(id string, address string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[id] = address
+}
+
+// Deregister 移除actor的登记
+func (r *Registry) Deregister(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.entries, id)
+}
+
+// DeregisterAddress 移除某个服务端上的全部登记，用于节点下线
+func (r *Registry) DeregisterAddress(address string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, addr := range r.entries {
+		if addr == address {
+			delete(r.entries, id)
+		}
+	}
+}
+
+// Resolve 实现Resolver
+func (r *Registry) Resolve(_ context.Context, id string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if addr, ok := r.entries[id]; ok {
+		return []string{addr}, nil
+	}
+	return nil, nil
+}
+
+// Lookup 获取id对应的actor引用，本地存在时返回本地引用，
+// 匹配本地激活前缀（虚拟actor、分片实体）时返回发送时按需激活的引用，否则通过解析器查找远程actor
+func (s *ActorSystem) Lookup(id string) (ActorRef, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	return s.LookupContext(ctx, id)
+}
+
+// LookupContext 同 Lookup，解析与连接受ctx控制
+func (s *ActorSystem) LookupContext(ctx context.Context, id string) (ActorRef, error) {
+	s.mu.RLock()
+	a, ok := s.actors[id]
+	_, activatable := s.activatorFor(id)
+	s.mu.RUnlock()
+	if ok {
+		return &localActorRef{actor: a, id: id}, nil
+	}
+	if activatable {
+		return &grainRef{sys: s, id: id}, nil
+	}
+
+	notFound := fmt.Errorf("%w: %s", ErrActorNotFound, id)
+	if s.opts.resolver == nil {
+		return nil, notFound
+	}
+	addrs, err := s.opts.resolver.Resolve(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	lastErr := notFound
+	for _, addr := range addrs {
+		ref, err := s.resolveOn(ctx, addr, id)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return ref, nil
+	}
+	return nil, lastErr
+}
+
+// resolveOn 通过到addr的共享连接确认id存在，返回该连接上的引用
+func (s *ActorSystem) resolveOn(ctx context.Context, addr string, id string) (ActorRef, error) {
+	ref, err := s.remotes.ref(addr, id)
+	if err != nil {
+		return nil, err
+	}
+	infos, err := ref.(*selectedRemoteRef).conn.listActors(ctx, ListOptions{ID: id})
+	if err != nil {
+		s.remotes.drop(addr, ref)
+		return nil, err
+	}
+	if len(infos) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrActorNotFound, id)
+	}
+	return ref, nil
+}
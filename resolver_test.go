@@ -0,0 +1,73 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	remote := NewActorSystem()
+	for _, id := range []string{"users/1", "users/3"} {
+		if _, err := remote.RegisterActor(id, func(msg interface{}) (interface{}, error) { return "remote", nil }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, addr := startServer(t, remote)
+	_, portStr, _ := net.SplitHostPort(addr)
+	port, _ := strconv.Atoi(portStr)
+
+	reg := NewRegistry()
+	reg.Register("users/1", addr)
+	reg.Register("users/3", addr)
+	tests := []struct {
+		name string
+		res  Resolver
+	}{
+		{"static", NewStaticResolver().Add("users/*", addr)},
+		// 第一个SRV目标不可达，应回退到下一个
+		{"srv", &SRVResolver{Service: "actor", Proto: "tcp", Name: "example.test",
+			LookupSRV: func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+				return "", []*net.SRV{{Target: "127.0.0.1.", Port: 1}, {Target: "127.0.0.1.", Port: uint16(port)}}, nil
+			}}},
+		{"registry", ChainResolver{NewStaticResolver(), reg}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sys := NewActorSystem(WithResolver(tt.res), WithLookupOptions(WithCodecs(CodecGob)))
+			defer sys.Shutdown()
+			if _, err := sys.RegisterActor("me", func(msg interface{}) (interface{}, error) { return "local", nil }); err != nil {
+				t.Fatal(err)
+			}
+			ctx := testContext(t)
+			var out string
+			ref, err := sys.Lookup("me")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := ref.Request(ctx, 1, &out); err != nil || out != "local" {
+				t.Fatal(out, err)
+			}
+			ref, err = sys.Lookup("users/1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := ref.Request(ctx, 1, &out); err != nil || out != "remote" {
+				t.Fatal(out, err)
+			}
+			// 同一地址上的actor共享一个连接
+			other, err := sys.Lookup("users/3")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if other.(*selectedRemoteRef).conn != ref.(*selectedRemoteRef).conn {
+				t.Fatal("lookups on one address opened separate connections")
+			}
+			if _, err := sys.Lookup("users/2"); !errors.Is(err, ErrActorNotFound) {
+				t.Fatalf("unknown actor: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ActorSelection 按 path.Match 通配符路径选中的一组actor，例如 "sessions/*" 或 "shard-?/worker-*"
+// 每次投递时重新匹配，期间新注册的actor同样会被选中
+type ActorSelection struct {
+	pattern string
+	sys     *ActorSystem    // 本地选择
+	conn    *remoteActorRef // 远程选择
+}
+
+// ActorSelection 选中本地系统中id匹配pattern的actor
+func (s *ActorSystem) ActorSelection(pattern string) *ActorSelection {
+	return &ActorSelection{pattern: pattern, sys: s}
+}
+
+// SelectRemote 选中address上的服务端中id匹配pattern的actor，选中的引用共用一个连接
+func SelectRemote(address string, pattern string, opts ...RemoteOption) (*ActorSelection, error) {
+	ref, err := NewRemoteActorRef("", address, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &ActorSelection{pattern: pattern, conn: ref.(*remoteActorRef)}, nil
+}
+
+// Pattern 返回选择的通配符路径
+func (sel *ActorSelection) Pattern() string {
+	return sel.pattern
+}
+
+// Tell 向全部匹配的actor投递单向消息，返回成功投递的数量
+// 部分actor投递失败时返回合并的错误，远程选择只投递给调用方有权访问的actor
+func (sel *ActorSelection) Tell(msg interface{}) (int, error) {
+	if sel.conn != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), sel.conn.sendTimeout)
+		defer cancel()
+		response, err := sel.conn.call(ctx, frameSelectTell, msg, func(m *remoteMessage) {
+			m.Target = sel.pattern
+		})
+		if err != nil {
+			return 0, err
+		}
+		var n int
+		if err := assignResult(response.Data, &n); err != nil {
+			return 0, err
+		}
+		return n, nil
+	}
+	return sel.sys.tellSelection(sel.pattern, Message{
+		Payload: msg,
+		Context: context.Background(),
+	})
+}
+
+// Identify 返回当前匹配的actor引用，按id排序
+func (sel *ActorSelection) Identify(ctx context.Context) ([]ActorRef, error) {
+	if sel.conn != nil {
+		infos, err := sel.conn.listActors(ctx, ListOptions{Pattern: sel.pattern})
+		if err != nil {
+			return nil, err
+		}
+		refs := make([]ActorRef, 0, len(infos))
+		for _, info := range infos {
+			refs = append(refs, &selectedRemoteRef{conn: sel.conn, id: info.ID})
+		}
+		return refs, nil
+	}
+
+	actors, err := sel.sys.selectActors(sel.pattern)
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]ActorRef, 0, len(actors))
+	for _, a := range actors {
+		refs = append(refs, &localActorRef{actor: a, id: a.id})
+	}
+	return refs, nil
+}
+
+// Close 关闭远程选择的连接，本地选择无需关闭
+func (sel *ActorSelection) Close() error {
+	if sel.conn != nil {
+		return sel.conn.Close()
+	}
+	return nil
+}
+
+// selectActors 返回id匹配pattern的actor，按id排序
+func (s *ActorSystem) selectActors(pattern string) ([]*Actor, error) {
+	opts := ListOptions{Pattern: pattern}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	actors := make([]*Actor, 0)
+	for id, a := range s.actors {
+		if opts.match(id, a.kind) {
+			actors = append(actors, a)
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(actors, func(i, j int) bool {
+		return actors[i].id < actors[j].id
+	})
+	return actors, nil
+}
+
+// tellSelection 向匹配pattern的actor逐个投递msg
+func (s *ActorSystem) tellSelection(pattern string, msg Message) (int, error) {
+	actors, err := s.selectActors(pattern)
+	if err != nil {
+		return 0, err
+	}
+	return deliverAll(actors, msg)
+}
+
+// tellSelection 向匹配pattern且调用方有权访问的actor投递msg
+func (s *ActorServer) tellSelection(p *Principal, pattern string, msg Message) (int, error) {
+	actors, err := s.sys.selectActors(pattern)
+	if err != nil {
+		return 0, err
+	}
+	if authz := s.opts.authorizer; authz != nil {
+		visible := actors[:0]
+		for _, a := range actors {
+			if authz.Authorize(p, a.id) == nil {
+				visible = append(visible, a)
+			}
+		}
+		actors = visible
+	}
+	return deliverAll(actors, msg)
+}
+
+func deliverAll(actors []*Actor, msg Message) (int, error) {
+	var errs []error
+	n := 0
+	for _, a := range actors {
+		if err := a.Send(msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", a.id, err))
+			continue
+		}
+		n++
+	}
+	return n, errors.Join(errs...)
+}
+
+// selectedRemoteRef 远程选择中的单个actor，与选择共用连接
+type selectedRemoteRef struct {
+	conn *remoteActorRef
+	id   string
+}
+
+func (r *selectedRemoteRef) target(m *remoteMessage) {
+	m.Target = r.id
+}
+
+func (r *selectedRemoteRef) Request(ctx context.Context, req interface{}, resp interface{}) error {
+	response, err := r.conn.call(ctx, frameRequest, req, r.target)
+	if err != nil {
+		return err
+	}
+	return assignResult(response.Data, resp)
+}
+
+func (r *selectedRemoteRef) Tell(msg interface{}) error {
+	return r.conn.tell(r.id, msg)
+}
+
+func (r *selectedRemoteRef) TellWithAck(ctx context.Context, msg interface{}) error {
+	_, err := r.conn.call(ctx, frameTellAck, msg, r.target)
+	return err
+}
+
+func (r *selectedRemoteRef) RequestStream(ctx context.Context, req interface{}) (*Stream, error) {
+	return r.conn.requestStream(ctx, r.id, req)
+}
+
+func (r *selectedRemoteRef) RequestUpload(ctx context.Context) (UploadStream, error) {
+	return r.conn.requestUpload(ctx, r.id)
+}
+
+func (r *selectedRemoteRef) ID() string {
+	return r.id
+}
+
+func (r *selectedRemoteRef) Address() string {
+	return r.conn.address
+}
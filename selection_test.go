@@ -0,0 +1,78 @@
+package actor
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestActorSelection(t *testing.T) {
+	sys := NewActorSystem()
+	var hits atomic.Int32
+	for _, id := range []string{"sessions/a", "sessions/b", "sessions/x/deep", "shard-1/worker-1", "shard-2/worker-9", "shard-10/worker-1", "admin"} {
+		if _, err := sys.RegisterActor(id, func(msg interface{}) (interface{}, error) {
+			if msg == "ping" {
+				hits.Add(1)
+			}
+			return msg, nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		pattern string
+		want    int
+	}{
+		{"shard-?/worker-*", 2},
+		{"sessions/*", 2},
+		{"*", 1},
+		{"*/*/*", 1},
+		{"nobody/*", 0},
+	}
+	for _, tt := range tests {
+		n, err := sys.ActorSelection(tt.pattern).Tell("ping")
+		if err != nil || n != tt.want {
+			t.Errorf("%q: delivered to %d (%v), want %d", tt.pattern, n, err, tt.want)
+		}
+	}
+	eventually(t, "pings handled", func() bool { return hits.Load() == 6 })
+
+	refs, err := sys.ActorSelection("sessions/*").Identify(testContext(t))
+	if err != nil || len(refs) != 2 || refs[0].ID() != "sessions/a" || refs[1].ID() != "sessions/b" {
+		t.Fatal(refs, err)
+	}
+	if _, err := sys.ActorSelection("[").Tell(1); err == nil {
+		t.Fatal("malformed pattern accepted")
+	}
+}
+
+func TestSelectRemote(t *testing.T) {
+	sys := NewActorSystem()
+	for _, id := range []string{"sessions/a", "sessions/b", "admin/x"} {
+		if _, err := sys.RegisterActor(id, echo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, addr := startServer(t, sys,
+		WithAuthenticator(TokenAuthenticator{"bob": "t0k"}),
+		WithAuthorizer(NewACL().Allow("bob", "sessions/*")))
+
+	// 远程选择只包含调用方有权访问的actor
+	sel, err := SelectRemote(addr, "*/*", WithCredentials(TokenCredentials("bob", "t0k")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sel.Close()
+	if n, err := sel.Tell("x"); err != nil || n != 2 {
+		t.Fatal(n, err)
+	}
+	ctx := testContext(t)
+	refs, err := sel.Identify(ctx)
+	if err != nil || len(refs) != 2 {
+		t.Fatal(refs, err)
+	}
+	var out string
+	if err := refs[1].Request(ctx, "hello", &out); err != nil || out != "hello" || refs[1].ID() != "sessions/b" {
+		t.Fatal(out, err)
+	}
+}
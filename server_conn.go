@@ -0,0 +1,435 @@
+package actor
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// serverConn 服务端的一个客户端连接
+type serverConn struct {
+	srv    *ActorServer
+	conn   net.Conn
+	reader *bufio.Reader
+	out    *frameWriter
+	wire   *wireConn
+	peer   *Peer
+	ctx    context.Context // 携带对端信息的连接上下文
+	hb     *heartbeat
+
+	// 进行中的请求，客户端取消时通过cancel中止服务端处理
+	inflightMu sync.Mutex
+	inflight   map[uint64]context.CancelFunc
+	streams    map[uint64]*flowControl // 流式请求的客户端窗口
+	uploads    map[uint64]*Stream      // 上传流中actor读取的分块
+}
+
+// newServerConn 完成TLS与协议握手
+func newServerConn(s *ActorServer, conn net.Conn) (*serverConn, error) {
+	reader := bufio.NewReaderSize(conn, 32*1024)
+	writer := bufio.NewWriterSize(conn, 32*1024)
+
+	_ = conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	if tc, ok := conn.(*tls.Conn); ok {
+		if err := tc.Handshake(); err != nil {
+			return nil, err
+		}
+	}
+	peer := newPeer(conn)
+	hs, err := serverHandshake(reader, writer, &s.opts, peer)
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	sc := &serverConn{
+		srv:    s,
+		conn:   conn,
+		reader: reader,
+		peer:   peer,
+		ctx:    withPeer(context.Background(), peer),
+		wire: &wireConn{
+			codec:      hs.codec,
+			compressor: hs.compressor,
+			threshold:  s.opts.compressionThreshold,
+			stats:      &s.stats,
+		},
+		hb:       newHeartbeat(s.opts.heartbeatInterval, s.opts.heartbeatMaxMissed),
+		inflight: make(map[uint64]context.CancelFunc),
+		streams:  make(map[uint64]*flowControl),
+		uploads:  make(map[uint64]*Stream),
+	}
+	sc.out = newFrameWriter(conn, writer, s.opts.writeQueueSize, s.opts.writeTimeout, func(error) {
+		conn.Close()
+	})
+	key := peer.NodeID
+	if key == "" {
+		key = peer.Addr.String()
+	}
+	sc.hb.attach(s.opts.failureDetector, key)
+	return sc, nil
+}
+
+// serve 读取并分发客户端帧，直到连接关闭
+func (c *serverConn) serve() {
+	defer c.cancelAll()
+	defer c.hb.detach()
+	go c.out.run()
+	defer c.out.close()
+
+	if c.srv.opts.heartbeatInterval > 0 {
+		done := make(chan struct{})
+		defer close(done)
+		go c.hb.run(done, func() {
+			c.sendControl(frame{Type: framePing})
+		}, func() {
+			c.conn.Close()
+		})
+	}
+
+	for {
+		f, err := readFrame(c.reader)
+		if err != nil {
+			return
+		}
+		c.hb.touch()
+
+		switch f.Type {
+		case framePing:
+			c.sendControl(frame{Type: framePong, ID: f.ID})
+			continue
+		case framePong:
+			c.hb.beat()
+			continue
+		case frameCancel:
+			c.finish(f.ID)
+			continue
+		case frameStreamCredit:
+			c.inflightMu.Lock()
+			flow := c.streams[f.ID]
+			c.inflightMu.Unlock()
+			if flow != nil {
+				flow.grant(int64(decodeCount(f.Payload)))
+			}
+			continue
+		case frameUploadChunk, frameUploadEnd:
+			c.handleUploadFrame(f)
+			continue
+		}
+
+		// 单帧解码失败只影响该消息，不会中断整个连接
+		msg, payload, err := c.wire.decodeMessage(f)
+		if err != nil {
+			if f.Type.expectsReply() {
+				c.fail(f, NewError(CodeInvalidMessage, fmt.Sprintf("decode request failed: %v", err)))
+			}
+			c.returnCredit(f.Type)
+			continue
+		}
+
+		// 列表查询与通配符投递不针对单个actor，按授权过滤匹配结果
+		if authz := c.srv.opts.authorizer; authz != nil && f.Type != frameList && f.Type != frameSelectTell {
+			if err := authz.Authorize(c.peer.Principal, msg.Target); err != nil {
+				if f.Type.expectsReply() {
+					c.fail(f, toError(err))
+				}
+				c.returnCredit(f.Type)
+				continue
+			}
+		}
+
+		switch f.Type {
+		case frameRequest:
+			c.dispatchRequest(f.ID, msg, payload)
+		case frameTell:
+			c.srv.sys.SendMessage(msg.Target, Message{
+				Payload: payload,
+				Context: c.ctx,
+			})
+			c.returnCredit(f.Type)
+		case frameTellAck:
+			c.dispatchTellAck(f.ID, msg, payload)
+			c.returnCredit(f.Type)
+		case frameStreamRequest:
+			c.dispatchStream(f.ID, msg, payload)
+		case frameUploadRequest:
+			c.dispatchUpload(f.ID, msg)
+		case frameList:
+			opts, _ := payload.(ListOptions)
+			infos, err := c.srv.list(c.peer.Principal, opts)
+			c.reply(f.ID, infos, toError(err))
+			c.returnCredit(f.Type)
+		case frameSelectTell:
+			n, err := c.srv.tellSelection(c.peer.Principal, msg.Target, Message{
+				Payload: payload,
+				Context: c.ctx,
+			})
+			c.reply(f.ID, n, toError(err))
+			c.returnCredit(f.Type)
+		case frameSpawn:
+			c.reply(f.ID, nil, toError(c.srv.spawn(c.peer.Principal, msg.Kind, msg.Target, payload)))
+			c.returnCredit(f.Type)
+		}
+	}
+}
+
+// dispatchRequest 异步投递请求，actor响应时直接回复，不占用任何等待中的goroutine
+func (c *serverConn) dispatchRequest(id uint64, msg *remoteMessage, payload interface{}) {
+	reqCtx, cancel := c.requestContext(msg)
+	if !c.begin(id, cancel) {
+		cancel()
+		c.reply(id, nil, toError(ErrServerBusy))
+		c.returnCredit(frameRequest)
+		return
+	}
+
+	// 已被客户端取消的请求不再回复
+	respond := func(resp Response) {
+		if c.finish(id) {
+			c.reply(id, resp.Data, resp.Error)
+		}
+	}
+	err := c.srv.sys.SendMessage(msg.Target, Message{
+		Payload: payload,
+		Context: reqCtx,
+		onReply: respond,
+	})
+	if err != nil {
+		respond(Response{Error: toError(err)})
+	}
+}
+
+// dispatchStream 异步投递流式请求，actor通过响应流逐块回复，结束时发送结束帧
+func (c *serverConn) dispatchStream(id uint64, msg *remoteMessage, payload interface{}) {
+	reqCtx, cancel := c.requestContext(msg)
+	if !c.begin(id, cancel) {
+		cancel()
+		c.endStream(id, toError(ErrServerBusy))
+		c.returnCredit(frameStreamRequest)
+		return
+	}
+
+	window := msg.Window
+	if window <= 0 {
+		window = DefaultStreamWindow
+	}
+	rs := &serverResponseStream{conn: c, id: id, ctx: reqCtx, flow: newFlowControl(window)}
+	c.inflightMu.Lock()
+	c.streams[id] = rs.flow
+	c.inflightMu.Unlock()
+
+	respond := func(resp Response) {
+		if resp.Error == nil && resp.Data != nil {
+			if err := rs.Send(resp.Data); err != nil {
+				resp.Error = toError(err)
+			}
+		}
+		if c.finish(id) {
+			c.endStream(id, resp.Error)
+		}
+	}
+	err := c.srv.sys.SendMessage(msg.Target, Message{
+		Payload: payload,
+		Context: withResponseStream(reqCtx, rs),
+		onReply: respond,
+	})
+	if err != nil {
+		respond(Response{Error: toError(err)})
+	}
+}
+
+// dispatchUpload 投递上传流，actor读取客户端随后发送的分块并回复一次响应
+func (c *serverConn) dispatchUpload(id uint64, msg *remoteMessage) {
+	reqCtx, cancel := c.requestContext(msg)
+	if !c.begin(id, cancel) {
+		cancel()
+		c.reply(id, nil, toError(ErrServerBusy))
+		c.returnCredit(frameUploadRequest)
+		return
+	}
+
+	window := msg.Window
+	if window <= 0 {
+		window = DefaultStreamWindow
+	}
+	st := newUploadInbox(reqCtx, int(window), func(n int) {
+		c.send(frame{Type: frameUploadCredit, ID: id, Payload: encodeCount(uint32(n))})
+	})
+	c.inflightMu.Lock()
+	c.uploads[id] = st
+	c.inflightMu.Unlock()
+
+	respond := func(resp Response) {
+		st.end(ErrStreamClosed)
+		if c.finish(id) {
+			c.reply(id, resp.Data, resp.Error)
+		}
+	}
+	err := c.srv.sys.SendMessage(msg.Target, Message{
+		Payload: st,
+		Context: reqCtx,
+		onReply: respond,
+	})
+	if err != nil {
+		respond(Response{Error: toError(err)})
+	}
+}
+
+// handleUploadFrame 将上传分块按到达顺序交给actor，结束帧标记上传完成
+func (c *serverConn) handleUploadFrame(f frame) {
+	c.inflightMu.Lock()
+	st := c.uploads[f.ID]
+	c.inflightMu.Unlock()
+	if st == nil {
+		return // 上传已结束或被取消
+	}
+	if f.Type == frameUploadEnd {
+		st.end(nil)
+		return
+	}
+
+	_, payload, err := c.wire.decodeMessage(f)
+	if err != nil {
+		st.end(fmt.Errorf("decode upload chunk failed: %w", err))
+		return
+	}
+	// 客户端遵守窗口额度时缓冲区不会溢出
+	select {
+	case st.chunks <- payload:
+	default:
+		st.end(ErrWindowExceeded)
+	}
+}
+
+// requestContext 根据请求的剩余超时创建处理上下文，在读取帧后立即调用，截止时间按本地时钟从帧到达时计算
+func (c *serverConn) requestContext(msg *remoteMessage) (context.Context, context.CancelFunc) {
+	if msg.Timeout > 0 {
+		return context.WithTimeout(c.ctx, time.Duration(msg.Timeout))
+	}
+	return context.WithCancel(c.ctx)
+}
+
+// dispatchTellAck 投递需要确认的单向消息，带发送方序号的消息按窗口去重
+func (c *serverConn) dispatchTellAck(id uint64, msg *remoteMessage, payload interface{}) {
+	dedup := c.srv.dedup
+	if dedup != nil && msg.Sender != "" && !dedup.mark(msg.Sender, msg.Seq) {
+		c.reply(id, nil, nil) // 重复投递: 已处理过，直接确认
+		return
+	}
+
+	err := c.srv.sys.SendMessage(msg.Target, Message{
+		Payload: payload,
+		Context: c.ctx,
+	})
+	if err != nil {
+		if dedup != nil && msg.Sender != "" {
+			dedup.unmark(msg.Sender, msg.Seq)
+		}
+		c.reply(id, nil, toError(err))
+		return
+	}
+	if dedup != nil && msg.Sender != "" {
+		dedup.commit(msg.Sender, msg.Seq)
+	}
+	c.reply(id, nil, nil)
+}
+
+// begin 登记进行中的请求，超过连接或全局上限时返回false
+func (c *serverConn) begin(id uint64, cancel context.CancelFunc) bool {
+	c.inflightMu.Lock()
+	defer c.inflightMu.Unlock()
+	if limit := c.srv.opts.maxInFlightPerConn; limit > 0 && len(c.inflight) >= limit {
+		return false
+	}
+	if !c.srv.acquire() {
+		return false
+	}
+	c.inflight[id] = cancel
+	return true
+}
+
+// finish 结束进行中的请求并归还额度，请求已结束时返回false
+func (c *serverConn) finish(id uint64) bool {
+	c.inflightMu.Lock()
+	cancel, ok := c.inflight[id]
+	if ok {
+		delete(c.inflight, id)
+		delete(c.streams, id)
+		delete(c.uploads, id)
+		cancel()
+		c.srv.release()
+	}
+	c.inflightMu.Unlock()
+
+	if ok {
+		c.returnCredit(frameRequest)
+	}
+	return ok
+}
+
+// returnCredit 消息处理完毕后向客户端归还一条额度
+func (c *serverConn) returnCredit(t frameType) {
+	if c.srv.opts.credits > 0 && t.consumesCredit() {
+		c.send(frame{Type: frameCredit, ID: 1})
+	}
+}
+
+// cancelAll 连接关闭时取消所有进行中的请求
+func (c *serverConn) cancelAll() {
+	c.inflightMu.Lock()
+	defer c.inflightMu.Unlock()
+	for id, cancel := range c.inflight {
+		delete(c.inflight, id)
+		delete(c.streams, id)
+		delete(c.uploads, id)
+		cancel()
+		c.srv.release()
+	}
+}
+
+// send 将帧交给写goroutine
+func (c *serverConn) send(f frame) error {
+	return c.out.enqueue(f)
+}
+
+// sendControl 发送心跳等控制帧，写队列已满时丢弃，避免对端半开时阻塞心跳检测
+func (c *serverConn) sendControl(f frame) {
+	c.out.tryEnqueue(f)
+}
+
+// reply 发送响应帧
+func (c *serverConn) reply(id uint64, result interface{}, e *Error) {
+	resp, err := newRemoteMessage(c.wire.codec, "", result)
+	if err != nil {
+		resp = &remoteMessage{Error: NewError(CodeInternal, fmt.Sprintf("encode response failed: %v", err))}
+	} else {
+		resp.Error = e
+	}
+	f, err := c.wire.encodeMessage(frameResponse, id, resp)
+	if err != nil {
+		return
+	}
+	c.send(f)
+}
+
+// endStream 发送流结束帧
+func (c *serverConn) endStream(id uint64, e *Error) {
+	f, err := c.wire.encodeMessage(frameStreamEnd, id, &remoteMessage{Error: e})
+	if err != nil {
+		return
+	}
+	c.send(f)
+}
+
+// fail 对无法处理的帧回复错误
+func (c *serverConn) fail(f frame, e *Error) {
+	if f.Type == frameStreamRequest {
+		c.endStream(f.ID, e)
+		return
+	}
+	c.reply(f.ID, nil, e)
+}
@@ -0,0 +1,464 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrShardNotOwned 收到消息的节点不是该分片的所有者，通常发生在成员变化期间
+	ErrShardNotOwned = errors.New("shard is not owned by this node")
+	// ErrShardUnavailable 集群中没有可以承载分片的成员
+	ErrShardUnavailable = errors.New("no member available for shard")
+)
+
+// 分片相关错误码
+const (
+	CodeShardNotOwned    = "shard_not_owned"
+	CodeShardUnavailable = "shard_unavailable"
+)
+
+func init() {
+	RegisterErrorCode(CodeShardNotOwned, ErrShardNotOwned, true)
+	RegisterErrorCode(CodeShardUnavailable, ErrShardUnavailable, true)
+}
+
+// DefaultNumShards 默认的分片数量
+const DefaultNumShards = 100
+
+// ShardEnvelope 通过分片区域引用发送给实体的消息
+type ShardEnvelope struct {
+	EntityID string
+	Message  interface{}
+}
+
+// ShardAllocationStrategy 决定分片由哪个成员承载，members为状态为up的成员，按地址排序且不为空
+// 所有节点需使用相同的策略，以便在相同的成员视图下得到相同的结果
+type ShardAllocationStrategy interface {
+	Allocate(shard int, members []Member) Member
+}
+
+// RendezvousAllocation 按最高随机权重(rendezvous)哈希分配分片，
+// 成员加入或离开时只有少量分片迁移
+type RendezvousAllocation struct{}
+
+// Allocate 实现ShardAllocationStrategy
+func (RendezvousAllocation) Allocate(shard int, members []Member) Member {
+	var best Member
+	var bestWeight uint64
+	for i, m := range members {
+		h := fnv.New64a()
+		_, _ = io.WriteString(h, strconv.Itoa(shard))
+		_, _ = io.WriteString(h, "/")
+		_, _ = io.WriteString(h, m.Address)
+		if w := mix64(h.Sum64()); i == 0 || w > bestWeight {
+			best, bestWeight = m, w
+		}
+	}
+	return best
+}
+
+// mix64 打散相近输入的哈希值(splitmix64终结函数)，使权重分布均匀
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// ShardingConfig 分片区域配置
+type ShardingConfig struct {
+	TypeName       string                                        // 实体类型，各节点的同类型区域共同承载全部分片
+	NumShards      int                                           // 分片数量，默认100，集群运行期间不可修改
+	Factory        func(entityID string) (ContextHandler, error) // 实体首次收到消息时创建处理函数
+	PassivateAfter time.Duration                                 // 实体空闲超过该时间后停止，0表示不钝化
+	Allocation     ShardAllocationStrategy                       // 默认为 RendezvousAllocation
+}
+
+// ShardRegion 某类实体在本节点上的分片区域
+// 实体按id哈希到分片，分片按分配策略归属于某个up成员；
+// 消息发往所有者节点，实体在首次收到消息时创建，成员变化后不再归属本节点的实体被停止，
+// 随后的消息在新的所有者上重新创建实体，实体需要保留的状态应自行持久化
+type ShardRegion struct {
+	cluster *Cluster
+	sys     *ActorSystem
+	cfg     ShardingConfig
+	prefix  string
+
+	mu       sync.Mutex
+	entities map[string]*shardEntity // 本节点上的实体，按实体actor id索引
+	stopping map[string]bool         // 正在停止的实体，停止完成前不重新激活
+
+	conns *connPool
+
+	rebalance   chan struct{}
+	unsubscribe func()
+	done        chan struct{}
+	stopOnce    sync.Once
+	wg          sync.WaitGroup
+}
+
+type shardEntity struct {
+	lastActive atomic.Int64
+}
+
+// NewShardRegion 在集群节点上启动cfg.TypeName类型的分片区域，集群中每个节点都应启动同类型的区域
+func NewShardRegion(c *Cluster, cfg ShardingConfig) (*ShardRegion, error) {
+	if cfg.TypeName == "" || cfg.Factory == nil {
+		return nil, fmt.Errorf("sharding: type name and factory are required")
+	}
+	if cfg.NumShards <= 0 {
+		cfg.NumShards = DefaultNumShards
+	}
+	if cfg.Allocation == nil {
+		cfg.Allocation = RendezvousAllocation{}
+	}
+	r := &ShardRegion{
+		cluster:   c,
+		sys:       c.sys,
+		cfg:       cfg,
+		prefix:    "$shard/" + cfg.TypeName + "/",
+		entities:  make(map[string]*shardEntity),
+		stopping:  make(map[string]bool),
+		conns:     newConnPool(c.cfg.RemoteOptions),
+		rebalance: make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+	r.sys.setActivator(r.prefix, activator{kind: cfg.TypeName, create: r.activate, rejected: r.redeliver})
+	r.unsubscribe = r.sys.EventStream().Subscribe(func(event interface{}) {
+		if _, ok := event.(MemberEvent); ok {
+			select {
+			case r.rebalance <- struct{}{}:
+			default:
+			}
+		}
+	})
+
+	r.wg.Add(1)
+	go r.loop()
+	return r, nil
+}
+
+// Ref 返回分片区域的引用，发送的消息需为 ShardEnvelope
+func (r *ShardRegion) Ref() ActorRef {
+	return &regionRef{region: r}
+}
+
+// Entity 返回实体的引用，发送的消息直接交给实体
+func (r *ShardRegion) Entity(entityID string) ActorRef {
+	return &entityRef{region: r, entityID: entityID}
+}
+
+// ShardOf 返回实体所在的分片
+func (r *ShardRegion) ShardOf(entityID string) int {
+	h := fnv.New32a()
+	_, _ = io.WriteString(h, entityID)
+	return int(h.Sum32() % uint32(r.cfg.NumShards))
+}
+
+// Owner 返回本节点视角下分片的所有者
+func (r *ShardRegion) Owner(shard int) (Member, error) {
+	up := r.upMembers()
+	if len(up) == 0 {
+		return Member{}, ErrShardUnavailable
+	}
+	return r.cfg.Allocation.Allocate(shard, up), nil
+}
+
+// upMembers 返回可以承载分片的成员
+func (r *ShardRegion) upMembers() []Member {
+	var up []Member
+	for _, m := range r.cluster.Members() {
+		if m.Status == MemberUp {
+			up = append(up, m)
+		}
+	}
+	return up
+}
+
+// LocalEntities 返回本节点上当前运行的实体数
+func (r *ShardRegion) LocalEntities() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.entities)
+}
+
+// Stop 停止本节点上的实体并释放连接
+func (r *ShardRegion) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.done)
+		r.wg.Wait()
+		r.unsubscribe()
+		r.sys.setActivator(r.prefix, activator{})
+
+		r.stopEntities(func(string, *shardEntity) bool {
+			return true
+		})
+
+		r.conns.close()
+	})
+}
+
+func (r *ShardRegion) loop() {
+	defer r.wg.Done()
+	var sweep <-chan time.Time
+	if r.cfg.PassivateAfter > 0 {
+		ticker := time.NewTicker(r.cfg.PassivateAfter / 2)
+		defer ticker.Stop()
+		sweep = ticker.C
+	}
+	for {
+		select {
+		case <-sweep:
+			r.passivateIdle()
+		case <-r.rebalance:
+			r.handOff()
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// activate 由ActorSystem在实体首次收到消息时调用，同一实体不会并发调用
+func (r *ShardRegion) activate(id string) (ContextHandler, error) {
+	entityID := id[len(r.prefix):]
+	owner, err := r.Owner(r.ShardOf(entityID))
+	if err != nil {
+		return nil, err
+	}
+	if owner.NodeID != r.cluster.self {
+		return nil, fmt.Errorf("%w: %s", ErrShardNotOwned, entityID)
+	}
+	// 上一个实例仍在处理剩余消息，返回可重试错误而不是等待，
+	// 避免该实例在停止过程中向自身发送消息时互相等待
+	r.mu.Lock()
+	stopping := r.stopping[id]
+	r.mu.Unlock()
+	if stopping {
+		return nil, fmt.Errorf("%w: %s", ErrActorStopped, entityID)
+	}
+	handler, err := r.cfg.Factory(entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &shardEntity{}
+	e.lastActive.Store(time.Now().UnixNano())
+	r.mu.Lock()
+	r.entities[id] = e
+	r.mu.Unlock()
+	return func(ctx context.Context, msg interface{}) (interface{}, error) {
+		e.lastActive.Store(time.Now().UnixNano())
+		return handler(ctx, msg)
+	}, nil
+}
+
+// passivateIdle 停止空闲的实体
+func (r *ShardRegion) passivateIdle() {
+	deadline := time.Now().Add(-r.cfg.PassivateAfter).UnixNano()
+	r.stopEntities(func(id string, e *shardEntity) bool {
+		return e.lastActive.Load() < deadline
+	})
+}
+
+// handOff 停止不再归属本节点的实体
+func (r *ShardRegion) handOff() {
+	up := r.upMembers()
+	r.stopEntities(func(id string, _ *shardEntity) bool {
+		return len(up) == 0 || r.cfg.Allocation.Allocate(r.ShardOf(id[len(r.prefix):]), up).NodeID != r.cluster.self
+	})
+}
+
+// redeliver 将实体停止时邮箱中尚未处理的单向消息按当前所有者重新路由，无法投递时发布 DeadLetter
+// 重新投递的消息与停止后发送的消息之间不保证顺序
+func (r *ShardRegion) redeliver(id string, msg interface{}) {
+	if err := r.Entity(id[len(r.prefix):]).Tell(msg); err != nil {
+		r.sys.EventStream().Publish(DeadLetter{Target: id, Message: msg, Err: err})
+	}
+}
+
+// stopEntities 停止满足条件的实体，停止时等待实体处理完当前消息
+func (r *ShardRegion) stopEntities(match func(id string, e *shardEntity) bool) {
+	var ids []string
+	r.mu.Lock()
+	for id, e := range r.entities {
+		if match(id, e) {
+			ids = append(ids, id)
+			delete(r.entities, id)
+			r.stopping[id] = true
+		}
+	}
+	r.mu.Unlock()
+	for _, id := range ids {
+		r.sys.DeregisterActor(id)
+		r.mu.Lock()
+		delete(r.stopping, id)
+		r.mu.Unlock()
+	}
+}
+
+// route 找到实体所有者并执行send，成员变化期间的可重试错误会在短暂等待后重试
+func (r *ShardRegion) route(ctx context.Context, entityID string, send func(ref ActorRef) error) error {
+	const maxAttempts = 5
+	backoff := r.cluster.cfg.GossipInterval / 2
+	for attempt := 1; ; attempt++ {
+		ref, err := r.resolve(entityID)
+		if err == nil {
+			err = send(ref)
+		}
+		if err == nil || attempt >= maxAttempts || !shardRetryable(err) {
+			return err
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-r.done:
+			return err
+		}
+	}
+}
+
+func shardRetryable(err error) bool {
+	return errors.Is(err, ErrShardNotOwned) ||
+		errors.Is(err, ErrShardUnavailable) ||
+		errors.Is(err, ErrActorStopped) ||
+		errors.Is(err, ErrConnectionClosed)
+}
+
+// resolve 返回实体在所有者节点上的引用
+func (r *ShardRegion) resolve(entityID string) (ActorRef, error) {
+	owner, err := r.Owner(r.ShardOf(entityID))
+	if err != nil {
+		return nil, err
+	}
+	id := r.prefix + entityID
+	if owner.NodeID == r.cluster.self {
+		actor, err := r.sys.actorFor(id)
+		if err != nil {
+			return nil, err
+		}
+		return &localActorRef{actor: actor, id: id}, nil
+	}
+
+	return r.conns.ref(owner.Address, id)
+}
+
+// entityRef 单个实体的引用，每次发送时按当前成员视图路由
+type entityRef struct {
+	region   *ShardRegion
+	entityID string
+}
+
+func (e *entityRef) Request(ctx context.Context, req interface{}, resp interface{}) error {
+	return e.region.route(ctx, e.entityID, func(ref ActorRef) error {
+		return ref.Request(ctx, req, resp)
+	})
+}
+
+func (e *entityRef) Tell(msg interface{}) error {
+	return e.region.route(context.Background(), e.entityID, func(ref ActorRef) error {
+		return ref.Tell(msg)
+	})
+}
+
+func (e *entityRef) TellWithAck(ctx context.Context, msg interface{}) error {
+	return e.region.route(ctx, e.entityID, func(ref ActorRef) error {
+		return ref.TellWithAck(ctx, msg)
+	})
+}
+
+func (e *entityRef) RequestStream(ctx context.Context, req interface{}) (*Stream, error) {
+	var st *Stream
+	err := e.region.route(ctx, e.entityID, func(ref ActorRef) (err error) {
+		st, err = ref.RequestStream(ctx, req)
+		return err
+	})
+	return st, err
+}
+
+func (e *entityRef) RequestUpload(ctx context.Context) (UploadStream, error) {
+	var up UploadStream
+	err := e.region.route(ctx, e.entityID, func(ref ActorRef) (err error) {
+		up, err = ref.RequestUpload(ctx)
+		return err
+	})
+	return up, err
+}
+
+func (e *entityRef) ID() string {
+	return e.region.prefix + e.entityID
+}
+
+func (e *entityRef) Address() string {
+	return "shard://" + e.region.cfg.TypeName + "/" + e.entityID
+}
+
+// regionRef 分片区域的引用，按 ShardEnvelope 中的实体id路由
+type regionRef struct {
+	region *ShardRegion
+}
+
+func (r *regionRef) entity(msg interface{}) (*entityRef, interface{}, error) {
+	switch env := msg.(type) {
+	case ShardEnvelope:
+		return &entityRef{region: r.region, entityID: env.EntityID}, env.Message, nil
+	case *ShardEnvelope:
+		return &entityRef{region: r.region, entityID: env.EntityID}, env.Message, nil
+	}
+	return nil, nil, fmt.Errorf("sharding: expected ShardEnvelope, got %T", msg)
+}
+
+func (r *regionRef) Request(ctx context.Context, req interface{}, resp interface{}) error {
+	e, msg, err := r.entity(req)
+	if err != nil {
+		return err
+	}
+	return e.Request(ctx, msg, resp)
+}
+
+func (r *regionRef) Tell(msg interface{}) error {
+	e, m, err := r.entity(msg)
+	if err != nil {
+		return err
+	}
+	return e.Tell(m)
+}
+
+func (r *regionRef) TellWithAck(ctx context.Context, msg interface{}) error {
+	e, m, err := r.entity(msg)
+	if err != nil {
+		return err
+	}
+	return e.TellWithAck(ctx, m)
+}
+
+func (r *regionRef) RequestStream(ctx context.Context, req interface{}) (*Stream, error) {
+	e, m, err := r.entity(req)
+	if err != nil {
+		return nil, err
+	}
+	return e.RequestStream(ctx, m)
+}
+
+// RequestUpload 上传流没有可供路由的消息，需通过 ShardRegion.Entity 获取实体引用
+func (r *regionRef) RequestUpload(ctx context.Context) (UploadStream, error) {
+	return nil, fmt.Errorf("sharding: upload streams require ShardRegion.Entity")
+}
+
+func (r *regionRef) ID() string {
+	return "$region/" + r.region.cfg.TypeName
+}
+
+func (r *regionRef) Address() string {
+	return "shard://" + r.region.cfg.TypeName
+}
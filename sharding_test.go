@@ -0,0 +1,216 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// counterFactory 每个实体独立计数，返回收到的消息数
+func counterFactory(string) (ContextHandler, error) {
+	n := 0
+	return func(context.Context, interface{}) (interface{}, error) {
+		n++
+		return n, nil
+	}, nil
+}
+
+// startRegions 在每个节点上启动同类型的分片区域
+func startRegions(t *testing.T, nodes []*testNode, cfg ShardingConfig) []*ShardRegion {
+	t.Helper()
+	regions := make([]*ShardRegion, len(nodes))
+	for i, n := range nodes {
+		r, err := NewShardRegion(n.c, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(r.Stop)
+		regions[i] = r
+	}
+	return regions
+}
+
+func TestShardingSingleInstance(t *testing.T) {
+	nodes := startCluster(t, 3)
+	regions := startRegions(t, nodes, ShardingConfig{TypeName: "counter", NumShards: 32, Factory: counterFactory})
+	ctx := testContext(t)
+
+	for i := 0; i < 30; i++ {
+		id := fmt.Sprintf("user-%d", i)
+		for k, r := range regions {
+			var n int
+			if err := r.Ref().Request(ctx, ShardEnvelope{EntityID: id, Message: "inc"}, &n); err != nil {
+				t.Fatal(err)
+			}
+			if n != k+1 {
+				t.Fatalf("%s: got %d from node %d, entity is not a single instance", id, n, k)
+			}
+		}
+	}
+	total := 0
+	for _, r := range regions {
+		total += r.LocalEntities()
+	}
+	if total != 30 {
+		t.Fatalf("%d entities across nodes, want 30", total)
+	}
+
+	// 离开的节点上的实体在剩余节点上重新创建
+	regions[2].Stop()
+	if err := nodes[2].c.Leave(ctx); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "member removed", func() bool { return allUp(nodes[:2]) })
+	for i := 0; i < 30; i++ {
+		var n int
+		if err := regions[0].Entity(fmt.Sprintf("user-%d", i)).Request(ctx, "inc", &n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	eventually(t, "entities handed off", func() bool {
+		return regions[0].LocalEntities()+regions[1].LocalEntities() == 30
+	})
+}
+
+func TestShardingConcurrentActivation(t *testing.T) {
+	nodes := startCluster(t, 1)
+	var created atomic.Int32
+	regions := startRegions(t, nodes, ShardingConfig{
+		TypeName: "counter",
+		Factory: func(id string) (ContextHandler, error) {
+			created.Add(1)
+			time.Sleep(20 * time.Millisecond) // 扩大并发激活的窗口
+			return counterFactory(id)
+		},
+	})
+	ctx := testContext(t)
+
+	const callers = 20
+	var wg sync.WaitGroup
+	results := make(chan int, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var n int
+			if err := regions[0].Entity("e").Request(ctx, "inc", &n); err != nil {
+				t.Error(err)
+				return
+			}
+			results <- n
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	if n := created.Load(); n != 1 {
+		t.Fatalf("factory ran %d times", n)
+	}
+	seen := make(map[int]bool)
+	for n := range results {
+		seen[n] = true
+	}
+	if len(seen) != callers {
+		t.Fatalf("messages were split across instances: %v", seen)
+	}
+	if n := regions[0].LocalEntities(); n != 1 {
+		t.Fatalf("%d local entities, want 1", n)
+	}
+}
+
+func TestShardingPassivateWhileSending(t *testing.T) {
+	nodes := startCluster(t, 1)
+	var region *ShardRegion
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	sent := make(chan error, 2)
+	region = startRegions(t, nodes, ShardingConfig{
+		TypeName:       "worker",
+		PassivateAfter: 50 * time.Millisecond,
+		Factory: func(id string) (ContextHandler, error) {
+			return func(ctx context.Context, msg interface{}) (interface{}, error) {
+				if msg != "block" {
+					return msg, nil
+				}
+				started <- struct{}{}
+				<-release
+				// 停止过程中仍可以向其他实体发送，向自身发送返回错误而不是死锁
+				var reply string
+				sent <- region.Entity("other").Request(ctx, "after", &reply)
+				sent <- region.Entity(id).Tell("again")
+				return nil, nil
+			}, nil
+		},
+	})[0]
+
+	if err := region.Entity("w").Tell("block"); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	// 处理期间lastActive不再更新，等待实体开始钝化
+	eventually(t, "entity passivating", func() bool { return region.LocalEntities() == 0 })
+	close(release)
+
+	for _, want := range []error{nil, ErrActorStopped} {
+		select {
+		case err := <-sent:
+			if !errors.Is(err, want) {
+				t.Fatalf("got %v, want %v", err, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("entity deadlocked sending while passivating")
+		}
+	}
+}
+
+func TestShardingRedeliversTellsQueuedAtPassivation(t *testing.T) {
+	nodes := startCluster(t, 1)
+	var dead atomic.Int32
+	nodes[0].sys.EventStream().Subscribe(func(e interface{}) {
+		if _, ok := e.(DeadLetter); ok {
+			dead.Add(1)
+		}
+	})
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	var received atomic.Int32
+	region := startRegions(t, nodes, ShardingConfig{
+		TypeName:       "inbox",
+		PassivateAfter: 50 * time.Millisecond,
+		Factory: func(id string) (ContextHandler, error) {
+			return func(ctx context.Context, msg interface{}) (interface{}, error) {
+				if msg == "block" {
+					started <- struct{}{}
+					<-release
+					return nil, nil
+				}
+				received.Add(1)
+				return nil, nil
+			}, nil
+		},
+	})[0]
+
+	entity := region.Entity("w")
+	if err := entity.Tell("block"); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	// 这些消息排在阻塞的处理函数之后，钝化时仍在邮箱中
+	const queued = 10
+	for i := 0; i < queued; i++ {
+		if err := entity.Tell(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	eventually(t, "entity passivating", func() bool { return region.LocalEntities() == 0 })
+	close(release)
+
+	eventually(t, "queued tells redelivered", func() bool { return received.Load() == queued })
+	if n := dead.Load(); n != 0 {
+		t.Fatalf("%d dead letters", n)
+	}
+}
@@ -0,0 +1,409 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrSingletonUnavailable 集群中暂时没有运行单例的成员，通常发生在交接期间
+var ErrSingletonUnavailable = errors.New("cluster singleton is unavailable")
+
+// CodeSingletonUnavailable 单例不可用的错误码
+const CodeSingletonUnavailable = "singleton_unavailable"
+
+func init() {
+	RegisterErrorCode(CodeSingletonUnavailable, ErrSingletonUnavailable, true)
+	RegisterMessageType[singletonHandover]("actor.singletonHandover")
+}
+
+// SingletonPlacement 单例所在成员的选择方式
+type SingletonPlacement int
+
+const (
+	SingletonOnOldest SingletonPlacement = iota // 运行在加入时间最早的up成员上
+	SingletonOnLeader                           // 运行在leader上
+)
+
+// 单例默认配置
+const (
+	DefaultSingletonBufferSize    = 1000
+	DefaultSingletonBufferTimeout = 30 * time.Second
+)
+
+// SingletonConfig 集群单例配置
+type SingletonConfig struct {
+	Name            string                         // 单例名称，各节点需一致
+	Factory         func() (ContextHandler, error) // 本节点成为宿主时创建处理函数
+	Placement       SingletonPlacement             // 默认为 SingletonOnOldest
+	BufferSize      int                            // 代理在交接期间缓存的单向消息数，默认1000
+	BufferTimeout   time.Duration                  // 消息等待单例可用的最长时间，默认30s
+	HandoverTimeout time.Duration                  // 等待上一任宿主停止单例的最长时间，默认为5个gossip周期
+}
+
+// singletonHandover 新宿主请求上一任宿主停止单例
+type singletonHandover struct {
+	Name string
+	From string // 新宿主的节点标识
+}
+
+// ClusterSingleton 集群范围内只运行一个实例的actor
+// 每个节点都应创建同名的单例管理器，单例运行在宿主成员上；
+// 宿主离开或失效后由新的宿主接管，新宿主先请求仍存活的上一任宿主停止实例再启动，
+// 单例需要保留的状态应自行持久化
+type ClusterSingleton struct {
+	cluster   *Cluster
+	sys       *ActorSystem
+	cfg       SingletonConfig
+	id        string // 单例actor id
+	managerID string // 本节点管理器actor id
+
+	mu       sync.Mutex
+	running  bool
+	previous Member // 本节点视角下的上一任宿主
+	yieldTo  string // 已将单例交给该节点，其存活期间本节点不再启动单例
+
+	conns   *connPool
+	pending chan pendingTell
+
+	changed     chan struct{}
+	unsubscribe func()
+	done        chan struct{}
+	stopOnce    sync.Once
+	wg          sync.WaitGroup
+}
+
+type pendingTell struct {
+	msg      interface{}
+	deadline time.Time
+}
+
+// NewClusterSingleton 在集群节点上启动cfg.Name单例的管理器
+func NewClusterSingleton(c *Cluster, cfg SingletonConfig) (*ClusterSingleton, error) {
+	if cfg.Name == "" || cfg.Factory == nil {
+		return nil, fmt.Errorf("singleton: name and factory are required")
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = DefaultSingletonBufferSize
+	}
+	if cfg.BufferTimeout <= 0 {
+		cfg.BufferTimeout = DefaultSingletonBufferTimeout
+	}
+	if cfg.HandoverTimeout <= 0 {
+		cfg.HandoverTimeout = 5 * c.cfg.GossipInterval
+	}
+	s := &ClusterSingleton{
+		cluster:   c,
+		sys:       c.sys,
+		cfg:       cfg,
+		id:        "$singleton/" + cfg.Name,
+		managerID: "$singleton-manager/" + cfg.Name,
+		conns:     newConnPool(c.cfg.RemoteOptions),
+		pending:   make(chan pendingTell, cfg.BufferSize),
+		changed:   make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+	if _, err := s.sys.RegisterContextActor(s.managerID, s.receive); err != nil {
+		return nil, fmt.Errorf("singleton: %w", err)
+	}
+	s.unsubscribe = s.sys.EventStream().Subscribe(func(event interface{}) {
+		switch event.(type) {
+		case MemberEvent, LeaderChanged:
+			select {
+			case s.changed <- struct{}{}:
+			default:
+			}
+		}
+	})
+
+	s.wg.Add(2)
+	go s.loop()
+	go s.deliver()
+	return s, nil
+}
+
+// Proxy 返回单例的引用，消息发往当前宿主，交接期间的消息会被缓存并在单例可用后投递
+func (s *ClusterSingleton) Proxy() ActorRef {
+	return &singletonProxy{s: s}
+}
+
+// Host 返回本节点视角下单例的宿主
+func (s *ClusterSingleton) Host() (Member, error) {
+	var up []Member
+	for _, m := range s.cluster.Members() {
+		if m.Status == MemberUp {
+			up = append(up, m)
+		}
+	}
+	if len(up) == 0 {
+		return Member{}, ErrSingletonUnavailable
+	}
+	if s.cfg.Placement == SingletonOnLeader {
+		leader := s.cluster.Leader()
+		for _, m := range up {
+			if m.Address == leader {
+				return m, nil
+			}
+		}
+		return Member{}, ErrSingletonUnavailable
+	}
+	sort.SliceStable(up, func(i, j int) bool {
+		return up[i].Joined < up[j].Joined
+	})
+	return up[0], nil
+}
+
+// Active 单例当前是否运行在本节点上
+func (s *ClusterSingleton) Active() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running
+}
+
+// Stop 停止本节点上的单例与管理器，缓存中尚未投递的消息被丢弃
+func (s *ClusterSingleton) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+		s.wg.Wait()
+		s.unsubscribe()
+		s.sys.DeregisterActor(s.managerID)
+		s.stopInstance("")
+		s.conns.close()
+	})
+}
+
+func (s *ClusterSingleton) loop() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.cluster.cfg.GossipInterval)
+	defer ticker.Stop()
+	for {
+		s.reconcile()
+		select {
+		case <-ticker.C:
+		case <-s.changed:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// reconcile 按当前成员视图启动或停止本节点上的单例
+func (s *ClusterSingleton) reconcile() {
+	host, err := s.Host()
+	self := s.cluster.self
+
+	s.mu.Lock()
+	previous := s.previous
+	if err == nil {
+		s.previous = host
+	}
+	if err != nil || host.NodeID != self {
+		s.mu.Unlock()
+		s.stopInstance("")
+		return
+	}
+	if s.running || (s.yieldTo != "" && s.isAlive(s.yieldTo)) {
+		s.mu.Unlock()
+		return
+	}
+	s.yieldTo = ""
+	s.mu.Unlock()
+
+	if previous.NodeID != "" && previous.NodeID != self && s.isAlive(previous.NodeID) {
+		s.handOver(previous.Address)
+	}
+	s.startInstance()
+}
+
+// isAlive 判断节点在本节点视角下是否仍参与集群
+func (s *ClusterSingleton) isAlive(nodeID string) bool {
+	for _, m := range s.cluster.Members() {
+		if m.NodeID == nodeID {
+			return m.alive()
+		}
+	}
+	return false
+}
+
+// handOver 请求上一任宿主停止单例，超时或对方不可达时直接接管
+func (s *ClusterSingleton) handOver(address string) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.HandoverTimeout)
+	defer cancel()
+	ref, err := s.conns.ref(address, s.managerID)
+	if err != nil {
+		return
+	}
+	_ = ref.Request(ctx, singletonHandover{Name: s.cfg.Name, From: s.cluster.self}, nil)
+}
+
+func (s *ClusterSingleton) startInstance() {
+	handler, err := s.cfg.Factory()
+	if err != nil {
+		return // 下一个周期重试
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return
+	}
+	if _, err := s.sys.RegisterContextActor(s.id, handler); err != nil {
+		return
+	}
+	s.running = true
+}
+
+// stopInstance 停止本节点上的单例，yieldTo非空时记录接管的节点
+func (s *ClusterSingleton) stopInstance(yieldTo string) {
+	s.mu.Lock()
+	running := s.running
+	s.running = false
+	if yieldTo != "" {
+		s.yieldTo = yieldTo
+	}
+	s.mu.Unlock()
+	if running {
+		s.sys.DeregisterActor(s.id)
+	}
+}
+
+// receive 处理其他节点管理器的交接请求
+func (s *ClusterSingleton) receive(_ context.Context, msg interface{}) (interface{}, error) {
+	req, ok := msg.(singletonHandover)
+	if !ok || req.Name != s.cfg.Name {
+		return nil, fmt.Errorf("singleton: unexpected message %T", msg)
+	}
+	s.stopInstance(req.From)
+	return true, nil
+}
+
+// deliver 按顺序投递缓存的单向消息
+func (s *ClusterSingleton) deliver() {
+	defer s.wg.Done()
+	for {
+		select {
+		case p := <-s.pending:
+			ctx, cancel := context.WithDeadline(context.Background(), p.deadline)
+			err := s.route(ctx, func(ref ActorRef) error {
+				return ref.TellWithAck(ctx, p.msg)
+			})
+			cancel()
+			if err != nil {
+				s.sys.EventStream().Publish(DeadLetter{Target: s.id, Message: p.msg, Err: err})
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// route 找到宿主并执行send，单例不可用时等待后重试，直到ctx结束或超过BufferTimeout
+func (s *ClusterSingleton) route(ctx context.Context, send func(ref ActorRef) error) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.BufferTimeout)
+		defer cancel()
+	}
+	backoff := s.cluster.cfg.GossipInterval / 4
+	for {
+		ref, err := s.resolve()
+		if err == nil {
+			err = send(ref)
+		}
+		if err == nil || !singletonRetryable(err) {
+			return err
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", err, ctx.Err())
+		case <-s.done:
+			return err
+		}
+	}
+}
+
+func singletonRetryable(err error) bool {
+	return errors.Is(err, ErrSingletonUnavailable) ||
+		errors.Is(err, ErrActorNotFound) ||
+		errors.Is(err, ErrActorStopped) ||
+		errors.Is(err, ErrConnectionClosed)
+}
+
+// resolve 返回单例在宿主上的引用
+func (s *ClusterSingleton) resolve() (ActorRef, error) {
+	host, err := s.Host()
+	if err != nil {
+		return nil, err
+	}
+	if host.NodeID == s.cluster.self {
+		actor, err := s.sys.actorFor(s.id)
+		if err != nil {
+			return nil, err
+		}
+		return &localActorRef{actor: actor, id: s.id}, nil
+	}
+	ref, err := s.conns.ref(host.Address, s.id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSingletonUnavailable, err)
+	}
+	return ref, nil
+}
+
+// singletonProxy 单例的引用，每次发送时按当前成员视图路由
+type singletonProxy struct {
+	s *ClusterSingleton
+}
+
+func (p *singletonProxy) Request(ctx context.Context, req interface{}, resp interface{}) error {
+	return p.s.route(ctx, func(ref ActorRef) error {
+		return ref.Request(ctx, req, resp)
+	})
+}
+
+// Tell 将消息放入缓存后立即返回，缓存已满时返回 ErrBackpressure，
+// 超过BufferTimeout仍无法投递的消息以 DeadLetter 事件发布
+func (p *singletonProxy) Tell(msg interface{}) error {
+	select {
+	case p.s.pending <- pendingTell{msg: msg, deadline: time.Now().Add(p.s.cfg.BufferTimeout)}:
+		return nil
+	case <-p.s.done:
+		return ErrActorStopped
+	default:
+		return ErrBackpressure
+	}
+}
+
+func (p *singletonProxy) TellWithAck(ctx context.Context, msg interface{}) error {
+	return p.s.route(ctx, func(ref ActorRef) error {
+		return ref.TellWithAck(ctx, msg)
+	})
+}
+
+func (p *singletonProxy) RequestStream(ctx context.Context, req interface{}) (*Stream, error) {
+	var st *Stream
+	err := p.s.route(ctx, func(ref ActorRef) (err error) {
+		st, err = ref.RequestStream(ctx, req)
+		return err
+	})
+	return st, err
+}
+
+func (p *singletonProxy) RequestUpload(ctx context.Context) (UploadStream, error) {
+	var up UploadStream
+	err := p.s.route(ctx, func(ref ActorRef) (err error) {
+		up, err = ref.RequestUpload(ctx)
+		return err
+	})
+	return up, err
+}
+
+func (p *singletonProxy) ID() string {
+	return p.s.id
+}
+
+func (p *singletonProxy) Address() string {
+	return "singleton://" + p.s.cfg.Name
+}
@@ -0,0 +1,124 @@
+package actor
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// startSingletons 在每个节点上启动coord单例，单例回复"who"时返回所在节点地址
+func startSingletons(t *testing.T, nodes []*testNode, received *atomic.Int32) []*ClusterSingleton {
+	t.Helper()
+	sms := make([]*ClusterSingleton, len(nodes))
+	for i, n := range nodes {
+		addr := n.addr
+		s, err := NewClusterSingleton(n.c, SingletonConfig{Name: "coord", Factory: func() (ContextHandler, error) {
+			return func(ctx context.Context, msg interface{}) (interface{}, error) {
+				if msg == "who" {
+					return addr, nil
+				}
+				received.Add(1)
+				return nil, nil
+			}, nil
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(s.Stop)
+		sms[i] = s
+	}
+	return sms
+}
+
+// activeCount 返回正在运行单例的节点数
+func activeCount(sms []*ClusterSingleton) int {
+	n := 0
+	for _, s := range sms {
+		if s.Active() {
+			n++
+		}
+	}
+	return n
+}
+
+func TestClusterSingleton(t *testing.T) {
+	nodes := startCluster(t, 3)
+	var received, dead atomic.Int32
+	for _, n := range nodes {
+		n.sys.EventStream().Subscribe(func(e interface{}) {
+			if _, ok := e.(DeadLetter); ok {
+				dead.Add(1)
+			}
+		})
+	}
+	sms := startSingletons(t, nodes, &received)
+
+	// 单例运行在最早加入的节点上
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var who string
+	if err := sms[2].Proxy().Request(ctx, "who", &who); err != nil || who != nodes[0].addr {
+		t.Fatal(who, err)
+	}
+	eventually(t, "single instance", func() bool { return activeCount(sms) == 1 && sms[0].Active() })
+
+	var maxActive atomic.Int32
+	stop := make(chan struct{})
+	watched := make(chan struct{})
+	go func() {
+		defer close(watched)
+		for {
+			if n := int32(activeCount(sms)); n > maxActive.Load() {
+				maxActive.Store(n)
+			}
+			select {
+			case <-stop:
+				return
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}()
+
+	// 宿主离开时持续发送，交接期间的消息被缓存而不是丢弃
+	proxy := sms[2].Proxy()
+	sent := int32(0)
+	for i := 0; i < 50; i++ {
+		if err := proxy.Tell(i); err != nil {
+			t.Fatal(err)
+		}
+		sent++
+	}
+	go func() {
+		_ = nodes[0].c.Leave(ctx)
+		sms[0].Stop()
+	}()
+	for i := 0; i < 100; i++ {
+		if err := proxy.Tell(i); err != nil {
+			t.Fatal(err)
+		}
+		sent++
+		time.Sleep(2 * time.Millisecond)
+	}
+	eventually(t, "handover", func() bool { return sms[1].Active() || sms[2].Active() })
+	eventually(t, "buffered messages delivered", func() bool { return received.Load()+dead.Load() == sent })
+	close(stop)
+	<-watched
+	if n := dead.Load(); n != 0 {
+		t.Fatalf("%d dead letters during handover", n)
+	}
+	if n := maxActive.Load(); n > 1 {
+		t.Fatalf("%d instances running at once during handover", n)
+	}
+
+	// 新宿主崩溃后剩下的节点接管
+	host, survivor := 1, 2
+	if sms[2].Active() {
+		host, survivor = 2, 1
+	}
+	sms[host].Stop()
+	nodes[host].crash()
+	if err := sms[survivor].Proxy().Request(ctx, "who", &who); err != nil || who != nodes[survivor].addr {
+		t.Fatal(who, err)
+	}
+}
@@ -0,0 +1,107 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+)
+
+// ErrKindNotFound 服务端未注册请求的actor类型
+var ErrKindNotFound = errors.New("actor kind not found")
+
+// ActorFactory 按id与创建参数构造actor的处理函数
+type ActorFactory func(id string, args interface{}) (ContextHandler, error)
+
+// SpawnPolicy 判断调用方是否可以在服务端创建指定类型的actor
+type SpawnPolicy interface {
+	AllowSpawn(p *Principal, kind string, id string) error
+}
+
+// SpawnPolicyFunc 函数形式的SpawnPolicy
+type SpawnPolicyFunc func(p *Principal, kind string, id string) error
+
+// AllowSpawn 实现SpawnPolicy
+func (f SpawnPolicyFunc) AllowSpawn(p *Principal, kind string, id string) error {
+	return f(p, kind, id)
+}
+
+// AllowKinds 允许任意调用方创建指定类型的actor，类型支持 path.Match 通配符
+func AllowKinds(kinds ...string) SpawnPolicy {
+	return SpawnPolicyFunc(func(p *Principal, kind string, id string) error {
+		for _, pattern := range kinds {
+			if ok, _ := path.Match(pattern, kind); ok {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: spawning %s is not allowed", ErrPermissionDenied, kind)
+	})
+}
+
+// kindRegistry 服务端可远程创建的actor类型
+type kindRegistry struct {
+	mu        sync.RWMutex
+	factories map[string]ActorFactory
+}
+
+func (k *kindRegistry) register(kind string, f ActorFactory) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.factories == nil {
+		k.factories = make(map[string]ActorFactory)
+	}
+	k.factories[kind] = f
+}
+
+func (k *kindRegistry) lookup(kind string) (ActorFactory, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	f, ok := k.factories[kind]
+	return f, ok
+}
+
+// RegisterKind 注册可由客户端远程创建的actor类型，需同时通过 WithSpawnPolicy 允许创建
+func (s *ActorServer) RegisterKind(kind string, factory ActorFactory) {
+	s.kinds.register(kind, factory)
+}
+
+// spawn 按类型创建actor并注册到系统
+func (s *ActorServer) spawn(p *Principal, kind string, id string, args interface{}) error {
+	policy := s.opts.spawnPolicy
+	if policy == nil {
+		return fmt.Errorf("%w: remote spawn is disabled", ErrPermissionDenied)
+	}
+	if err := policy.AllowSpawn(p, kind, id); err != nil {
+		return err
+	}
+	factory, ok := s.kinds.lookup(kind)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrKindNotFound, kind)
+	}
+	handler, err := factory(id, args)
+	if err != nil {
+		return err
+	}
+	actor := NewContextActor(id, handler)
+	actor.kind = kind
+	_, err = s.sys.register(actor)
+	return err
+}
+
+// SpawnRemote 在address上的服务端按类型创建id为id的actor，返回该actor的远程引用
+func SpawnRemote(ctx context.Context, address string, kind string, id string, args interface{}, opts ...RemoteOption) (ActorRef, error) {
+	ref, err := NewRemoteActorRef(id, address, opts...)
+	if err != nil {
+		return nil, err
+	}
+	r := ref.(*remoteActorRef)
+	_, err = r.call(ctx, frameSpawn, args, func(m *remoteMessage) {
+		m.Kind = kind
+	})
+	if err != nil {
+		_ = r.Close()
+		return nil, err
+	}
+	return ref, nil
+}
@@ -0,0 +1,73 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSpawnRemote(t *testing.T) {
+	sys := NewActorSystem()
+	srv := NewActorServer(sys, 100, WithSpawnPolicy(AllowKinds("counter*")))
+	srv.RegisterKind("counter", func(id string, args interface{}) (ContextHandler, error) {
+		var n int
+		if err := assignResult(args, &n); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, msg interface{}) (interface{}, error) {
+			n++
+			return n, nil
+		}, nil
+	})
+	srv.RegisterKind("secret", func(id string, args interface{}) (ContextHandler, error) {
+		return nil, errors.New("must not be created")
+	})
+	if err := srv.Serve("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = srv.Stop() })
+	addr := srv.Addr().String()
+	ctx := testContext(t)
+
+	ref, err := SpawnRemote(ctx, addr, "counter", "c1", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ref.(*remoteActorRef).Close()
+	var n int
+	if err := ref.Request(ctx, "inc", &n); err != nil || n != 11 {
+		t.Fatal(n, err)
+	}
+	if infos, _ := sys.List(ListOptions{ID: "c1"}); len(infos) != 1 || infos[0].Kind != "counter" {
+		t.Fatalf("spawned actor listed as %+v", infos)
+	}
+
+	tests := []struct {
+		name string
+		kind string
+		id   string
+		err  error
+	}{
+		{"existing id", "counter", "c1", ErrActorExists},
+		{"denied by policy", "secret", "s", ErrPermissionDenied},
+		{"unknown kind", "counter2", "s", ErrKindNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := SpawnRemote(ctx, addr, tt.kind, tt.id, nil); !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestSpawnDisabledByDefault(t *testing.T) {
+	sys := NewActorSystem()
+	srv, addr := startServer(t, sys)
+	srv.RegisterKind("counter", func(id string, args interface{}) (ContextHandler, error) {
+		return func(ctx context.Context, msg interface{}) (interface{}, error) { return msg, nil }, nil
+	})
+	if _, err := SpawnRemote(testContext(t), addr, "counter", "c1", nil); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("spawn without a policy: %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package actor
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultStreamWindow 流式响应的默认接收窗口，即未被消费的分块上限
+const DefaultStreamWindow = 64
+
+// ResponseStream 流式请求中由actor向调用方逐块发送响应
+type ResponseStream interface {
+	// Send 发送一个分块，接收方窗口已满时阻塞，调用方取消后返回错误
+	Send(chunk interface{}) error
+}
+
+type responseStreamKey struct{}
+
+// withResponseStream 将响应流写入消息上下文
+func withResponseStream(ctx context.Context, s ResponseStream) context.Context {
+	return context.WithValue(ctx, responseStreamKey{}, s)
+}
+
+// ResponseStreamFromContext 获取流式请求的响应流，普通请求返回false
+// 处理函数返回后流结束；返回非nil结果时该结果作为最后一个分块发送，返回错误时调用方收到该错误
+func ResponseStreamFromContext(ctx context.Context) (ResponseStream, bool) {
+	s, ok := ctx.Value(responseStreamKey{}).(ResponseStream)
+	return s, ok
+}
+
+// Stream 调用方接收流式响应的迭代器
+type Stream struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	chunks chan interface{}
+
+	done    chan struct{}
+	endOnce sync.Once
+	err     error // 流结束的原因，正常结束时为nil
+	closed  atomic.Bool
+
+	onConsume func() // 每消费一个分块调用一次，用于归还窗口
+}
+
+func newStream(ctx context.Context, window int) *Stream {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Stream{
+		ctx:    ctx,
+		cancel: cancel,
+		chunks: make(chan interface{}, window),
+		done:   make(chan struct{}),
+	}
+}
+
+// Next 返回下一个分块，流正常结束时返回 io.EOF
+func (s *Stream) Next() (interface{}, error) {
+	if s.closed.Load() {
+		return nil, ErrStreamClosed
+	}
+	for {
+		// 结束前已到达的分块仍然有效
+		select {
+		case c := <-s.chunks:
+			s.consumed()
+			return c, nil
+		default:
+		}
+		select {
+		case <-s.done:
+			if len(s.chunks) > 0 {
+				continue
+			}
+			if s.err != nil {
+				return nil, s.err
+			}
+			return nil, io.EOF
+		default:
+		}
+
+		select {
+		case c := <-s.chunks:
+			s.consumed()
+			return c, nil
+		case <-s.done:
+		case <-s.ctx.Done():
+			select {
+			case <-s.done:
+				continue
+			default:
+			}
+			return nil, s.ctx.Err()
+		}
+	}
+}
+
+// Recv 将下一个分块写入v，流正常结束时返回 io.EOF
+func (s *Stream) Recv(v interface{}) error {
+	c, err := s.Next()
+	if err != nil {
+		return err
+	}
+	return assignResult(c, v)
+}
+
+// Close 提前结束流并丢弃未读取的分块，发送方随后的Send返回错误
+func (s *Stream) Close() {
+	s.closed.Store(true)
+	s.end(context.Canceled)
+}
+
+func (s *Stream) consumed() {
+	if s.onConsume != nil {
+		s.onConsume()
+	}
+}
+
+// push 放入一个分块，窗口已满时等待直到消费或取消
+func (s *Stream) push(chunk interface{}) error {
+	select {
+	case <-s.done:
+		return ErrStreamClosed
+	default:
+	}
+	select {
+	case s.chunks <- chunk:
+		return nil
+	case <-s.done:
+		return ErrStreamClosed
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+}
+
+// end 结束流并释放上下文
+func (s *Stream) end(err error) {
+	s.endOnce.Do(func() {
+		s.err = err
+		close(s.done)
+		s.cancel()
+	})
+}
+
+// ErrStreamClosed 流已结束
+var ErrStreamClosed = errors.New("stream is closed")
+
+// ErrWindowExceeded 对端发送的分块超过了接收窗口，属于协议错误，流被终止
+var ErrWindowExceeded = errors.New("stream window exceeded")
+
+// localResponseStream 本地actor的响应流，直接写入调用方的Stream
+type localResponseStream struct {
+	stream *Stream
+}
+
+func (l *localResponseStream) Send(chunk interface{}) error {
+	return l.stream.push(chunk)
+}
+
+// serverResponseStream 通过ActorServer发送的响应流，受客户端窗口额度限制
+type serverResponseStream struct {
+	conn *serverConn
+	id   uint64
+	ctx  context.Context
+	flow *flowControl
+}
+
+func (s *serverResponseStream) Send(chunk interface{}) error {
+	if err := s.flow.acquire(s.ctx, s.conn.out.done); err != nil {
+		if s.ctx.Err() != nil {
+			return s.ctx.Err() // 调用方已取消
+		}
+		return err
+	}
+	m, err := newRemoteMessage(s.conn.wire.codec, "", chunk)
+	if err != nil {
+		return err
+	}
+	f, err := s.conn.wire.encodeMessage(frameStreamChunk, s.id, m)
+	if err != nil {
+		return err
+	}
+	return s.conn.out.enqueueContext(s.ctx, f)
+}
+
+// encodeCount 编码窗口额度帧的负载
+func encodeCount(n uint32) []byte {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], n)
+	return b[:]
+}
+
+// decodeCount 解码窗口额度帧的负载
+func decodeCount(b []byte) uint32 {
+	if len(b) < 4 {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
@@ -0,0 +1,138 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// rowsActor 以流的形式返回msg个整数，最后返回"done"
+func rowsActor(stopped chan<- error) ContextHandler {
+	return func(ctx context.Context, msg interface{}) (interface{}, error) {
+		rs, ok := ResponseStreamFromContext(ctx)
+		if !ok {
+			return nil, nil
+		}
+		var n int
+		if err := assignResult(msg, &n); err != nil {
+			return nil, err
+		}
+		for i := 0; i < n; i++ {
+			if err := rs.Send(i); err != nil {
+				stopped <- err
+				return nil, err
+			}
+		}
+		return "done", nil
+	}
+}
+
+func TestRequestStream(t *testing.T) {
+	sys := NewActorSystem()
+	stopped := make(chan error, 1)
+	local, _ := sys.RegisterContextActor("rows", rowsActor(stopped))
+	_, addr := startServer(t, sys)
+	remote := dial(t, "rows", addr, WithStreamWindow(8))
+
+	for _, tt := range []struct {
+		name string
+		ref  ActorRef
+	}{{"local", local}, {"remote", remote}} {
+		t.Run(tt.name, func(t *testing.T) {
+			st, err := tt.ref.RequestStream(testContext(t), 1000)
+			if err != nil {
+				t.Fatal(err)
+			}
+			count := 0
+			var last interface{}
+			for {
+				v, err := st.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				last = v
+				count++
+			}
+			if count != 1001 || last != "done" {
+				t.Fatalf("got %d chunks, last %v", count, last)
+			}
+
+			// 调用方关闭流后生产者停止
+			st, err = tt.ref.RequestStream(testContext(t), 1000000)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 5; i++ {
+				var x int
+				if err := st.Recv(&x); err != nil || x != i {
+					t.Fatalf("chunk %d: got %d, %v", i, x, err)
+				}
+			}
+			st.Close()
+			select {
+			case <-stopped:
+			case <-time.After(2 * time.Second):
+				t.Fatal("producer not stopped")
+			}
+			if _, err := st.Next(); err == nil {
+				t.Fatal("expected error after close")
+			}
+		})
+	}
+}
+
+func TestStreamWindowOverflow(t *testing.T) {
+	sys := NewActorSystem()
+	started, cancelled := make(chan struct{}), make(chan struct{})
+	_, _ = sys.RegisterContextActor("hold", func(ctx context.Context, _ interface{}) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+		return nil, ctx.Err()
+	})
+	_, addr := startServer(t, sys)
+	ref := dial(t, "hold", addr, WithStreamWindow(2)).(*remoteActorRef)
+
+	st, err := ref.RequestStream(testContext(t), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	var id int64
+	ref.pendingMu.RLock()
+	for k := range ref.streams {
+		id = k
+	}
+	ref.pendingMu.RUnlock()
+
+	// 模拟不遵守窗口的服务端：发送超过窗口的分块
+	for i := 0; i < 3; i++ {
+		m, err := newRemoteMessage(ref.wire.codec, "", i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := ref.wire.encodeMessage(frameStreamChunk, uint64(id), m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ref.handleStreamFrame(f)
+	}
+
+	var err2 error
+	for err2 == nil {
+		_, err2 = st.Next()
+	}
+	if !errors.Is(err2, ErrWindowExceeded) {
+		t.Fatalf("got %v, want ErrWindowExceeded", err2)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("server stream not cancelled")
+	}
+}
@@ -2,6 +2,7 @@ package actor
 
 import (
     "fmt"
+    "strings"
     "sync"
 )
 
@@ -9,24 +10,73 @@ import (
 type ActorSystem struct {
     actors map[string]*Actor
     mu     sync.RWMutex
+    opts   systemOptions
+    events *EventStream
+    
+    // Lookup 使用的远程连接，按地址复用
+    remotes *connPool
+    
+    // 按id前缀在首次收到消息时创建actor
+    activators map[string]activator
+    activating map[string]chan struct{} // 正在创建的id，创建完成后关闭
+    
+    // 已注册的虚拟actor类型
+    grainsMu sync.Mutex
+    grains   map[string]*grainKind
+}
+
+// activator 为尚不存在的actor创建处理函数
+type activator struct {
+    kind   string
+    create func(id string) (ContextHandler, error)
+    
+    // rejected 接收actor停止时未处理的单向消息，用于投递到下一次激活，在新的goroutine中调用
+    rejected func(id string, msg interface{})
 }
 
 // NewActorSystem 创建一个新的actor系统
-func NewActorSystem() *ActorSystem {
-    return &ActorSystem{
+func NewActorSystem(opts ...SystemOption) *ActorSystem {
+    s := &ActorSystem{
         actors: make(map[string]*Actor),
+        events: newEventStream(),
+        
+        activators: make(map[string]activator),
+        activating: make(map[string]chan struct{}),
+        grains:     make(map[string]*grainKind),
+    }
+    for _, opt := range opts {
+        opt(&s.opts)
     }
+    s.remotes = newConnPool(s.opts.remoteOptions)
+    return s
+}
+
+// EventStream 返回系统的事件总线
+func (s *ActorSystem) EventStream() *EventStream {
+    return s.events
 }
 
 // RegisterActor 注册一个actor到系统
 func (s *ActorSystem) RegisterActor(id string, handler MessageHandler) (ActorRef, error) {
+    return s.register(NewActor(id, handler))
+}
+
+// RegisterContextActor 注册一个使用ContextHandler的actor到系统
+func (s *ActorSystem) RegisterContextActor(id string, handler ContextHandler) (ActorRef, error) {
+    return s.register(NewContextActor(id, handler))
+}
+
+func (s *ActorSystem) register(actor *Actor) (ActorRef, error) {
+    id := actor.id
     s.mu.Lock()
     defer s.mu.Unlock()
     
     if _, exists := s.actors[id]; exists {
-        return nil, fmt.Errorf("actor %s already exists", id)
+        return nil, fmt.Errorf("%w: %s", ErrActorExists, id)
+    }
+    if _, pending := s.activating[id]; pending {
+        return nil, fmt.Errorf("%w: %s", ErrActorExists, id)
     }
-    actor := NewActor(id, handler)
     
     s.actors[actor.id] = actor
     actor.Start()
@@ -37,37 +87,126 @@ func (s *ActorSystem) RegisterActor(id string, handler MessageHandler) (ActorRef
     return ref, nil
 }
 
-// DeregisterActor 从系统中移除一个actor
+// DeregisterActor 从系统中移除一个actor，等待其处理完当前消息
+// 停止时不持有系统锁，处理函数仍可以向其他actor发送消息
 func (s *ActorSystem) DeregisterActor(id string) {
     s.mu.Lock()
-    defer s.mu.Unlock()
+    for {
+        pending, ok := s.activating[id]
+        if !ok {
+            break
+        }
+        s.mu.Unlock()
+        <-pending // 等待进行中的激活完成，避免其创建的actor在移除后才加入
+        s.mu.Lock()
+    }
+    actor, exists := s.actors[id]
+    delete(s.actors, id)
+    s.mu.Unlock()
     
-    if actor, exists := s.actors[id]; exists {
+    if exists {
         actor.Stop()
-        delete(s.actors, id)
     }
 }
 
 // SendMessage 发送消息到指定的actor
 func (s *ActorSystem) SendMessage(to string, msg Message) error {
+    actor, err := s.actorFor(to)
+    if err != nil {
+        return err
+    }
+    return actor.Send(msg)
+}
+
+// actorFor 返回id对应的actor，不存在但匹配激活前缀时创建
+// 同一id同时只有一个调用方执行创建，其他调用方等待创建完成，激活函数不能向正在创建的id发送消息
+func (s *ActorSystem) actorFor(id string) (*Actor, error) {
     s.mu.RLock()
-    actor, exists := s.actors[to]
+    actor, exists := s.actors[id]
     s.mu.RUnlock()
-    
-    if !exists {
-        return fmt.Errorf("actor %s not found", to)
+    if exists {
+        return actor, nil
     }
     
-    return actor.Send(msg)
+    for {
+        s.mu.Lock()
+        if actor, exists := s.actors[id]; exists {
+            s.mu.Unlock()
+            return actor, nil
+        }
+        if pending, ok := s.activating[id]; ok {
+            s.mu.Unlock()
+            <-pending
+            continue
+        }
+        
+        act, ok := s.activatorFor(id)
+        if !ok {
+            s.mu.Unlock()
+            return nil, fmt.Errorf("%w: %s", ErrActorNotFound, id)
+        }
+        done := make(chan struct{})
+        s.activating[id] = done
+        s.mu.Unlock()
+        
+        handler, err := act.create(id)
+        
+        s.mu.Lock()
+        delete(s.activating, id)
+        close(done)
+        if err != nil {
+            s.mu.Unlock()
+            return nil, err
+        }
+        actor := NewContextActor(id, handler)
+        actor.kind = act.kind
+        if act.rejected != nil {
+            actor.rejected = func(msg Message) {
+                go act.rejected(id, msg.Payload)
+            }
+        }
+        s.actors[id] = actor
+        actor.Start()
+        s.mu.Unlock()
+        return actor, nil
+    }
+}
+
+// activatorFor 返回id匹配的最长激活前缀对应的激活函数，调用方需持有锁
+func (s *ActorSystem) activatorFor(id string) (activator, bool) {
+    var act activator
+    matched := ""
+    for prefix, a := range s.activators {
+        if strings.HasPrefix(id, prefix) && len(prefix) > len(matched) {
+            act, matched = a, prefix
+        }
+    }
+    return act, matched != ""
+}
+
+// setActivator 为以prefix开头的id设置激活函数，act.create为nil时移除
+func (s *ActorSystem) setActivator(prefix string, act activator) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if act.create == nil {
+        delete(s.activators, prefix)
+        return
+    }
+    s.activators[prefix] = act
 }
 
 // Shutdown 关闭整个actor系统
 func (s *ActorSystem) Shutdown() {
+    s.stopGrains() // 停用时需要注销actor，不能持有锁
+    
     s.mu.Lock()
-    defer s.mu.Unlock()
+    actors := s.actors
+    s.actors = make(map[string]*Actor)
+    s.mu.Unlock()
     
-    for _, actor := range s.actors {
+    for _, actor := range actors {
         actor.Stop()
     }
-    s.actors = make(map[string]*Actor)
+    
+    s.remotes.close()
 }
@@ -0,0 +1,145 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestActorConcurrentSendStop(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		a := NewActor("a", echo)
+		a.Start()
+		var wg sync.WaitGroup
+		for j := 0; j < 8; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for k := 0; k < 20; k++ {
+					err := a.Send(Message{Payload: k})
+					if err != nil && !errors.Is(err, ErrActorStopped) && !errors.Is(err, ErrMailboxFull) {
+						t.Error(err)
+						return
+					}
+				}
+			}()
+		}
+		a.Stop()
+		wg.Wait()
+		if err := a.Send(Message{Payload: 0}); !errors.Is(err, ErrActorStopped) {
+			t.Fatalf("send after stop: %v", err)
+		}
+	}
+}
+
+func TestDeregisterWhileHandlerSends(t *testing.T) {
+	sys := NewActorSystem()
+	if _, err := sys.RegisterActor("other", echo); err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	sent := make(chan error, 1)
+	ref, err := sys.RegisterActor("worker", func(msg interface{}) (interface{}, error) {
+		close(started)
+		<-release
+		// 注销期间处理函数仍然可以访问系统
+		sent <- sys.SendMessage("other", Message{Payload: "bye"})
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ref.Tell("work"); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	deregistered := make(chan struct{})
+	go func() {
+		sys.DeregisterActor("worker")
+		close(deregistered)
+	}()
+	eventually(t, "worker removed", func() bool {
+		_, err := sys.actorFor("worker")
+		return errors.Is(err, ErrActorNotFound)
+	})
+	close(release)
+
+	select {
+	case <-deregistered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("DeregisterActor deadlocked with a handler sending messages")
+	}
+	if err := <-sent; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestActorForActivatesOnce(t *testing.T) {
+	sys := NewActorSystem()
+	var created atomic.Int32
+	sys.setActivator("lazy/", activator{kind: "lazy", create: func(id string) (ContextHandler, error) {
+		created.Add(1)
+		time.Sleep(20 * time.Millisecond) // 扩大并发激活的窗口
+		return func(_ context.Context, msg interface{}) (interface{}, error) { return msg, nil }, nil
+	}})
+
+	const callers = 32
+	actors := make([]*Actor, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			a, err := sys.actorFor("lazy/1")
+			if err != nil {
+				t.Error(err)
+			}
+			actors[i] = a
+		}(i)
+	}
+	wg.Wait()
+	if n := created.Load(); n != 1 {
+		t.Fatalf("activator ran %d times", n)
+	}
+	for _, a := range actors {
+		if a != actors[0] {
+			t.Fatal("callers got different actors")
+		}
+	}
+	if _, err := sys.RegisterActor("lazy/1", echo); !errors.Is(err, ErrActorExists) {
+		t.Fatalf("register over activated actor: %v", err)
+	}
+}
+
+func TestDeregisterWaitsForActivation(t *testing.T) {
+	sys := NewActorSystem()
+	creating := make(chan struct{})
+	release := make(chan struct{})
+	sys.setActivator("lazy/", activator{kind: "lazy", create: func(id string) (ContextHandler, error) {
+		close(creating)
+		<-release
+		return func(_ context.Context, msg interface{}) (interface{}, error) { return msg, nil }, nil
+	}})
+	activated := make(chan *Actor, 1)
+	go func() {
+		a, _ := sys.actorFor("lazy/1")
+		activated <- a
+	}()
+	<-creating
+
+	deregistered := make(chan struct{})
+	go func() {
+		sys.DeregisterActor("lazy/1")
+		close(deregistered)
+	}()
+	close(release)
+	<-deregistered
+	if a := <-activated; a == nil || !a.IsStopped() {
+		t.Fatal("actor activated during deregistration was left running")
+	}
+}
@@ -0,0 +1,54 @@
+package actor
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// startServer 在随机端口上启动sys的服务端，测试结束时停止
+func startServer(t *testing.T, sys *ActorSystem, opts ...ServerOption) (*ActorServer, string) {
+	t.Helper()
+	srv := NewActorServer(sys, 100, opts...)
+	if err := srv.Serve("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = srv.Stop() })
+	return srv, srv.Addr().String()
+}
+
+// dial 连接address上的id，测试结束时关闭连接
+func dial(t *testing.T, id, address string, opts ...RemoteOption) ActorRef {
+	t.Helper()
+	ref, err := NewRemoteActorRef(id, address, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ref.(*remoteActorRef).Close() })
+	return ref
+}
+
+// echo 原样返回消息的处理函数
+func echo(msg interface{}) (interface{}, error) {
+	return msg, nil
+}
+
+// testContext 返回带超时的上下文，测试结束时取消
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+// eventually 在超时前反复检查cond
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
@@ -0,0 +1,143 @@
+package actor
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+// selfSigned 生成测试用的自签名证书
+func selfSigned(t *testing.T, cn string) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+// whoami 返回对端证书CN与认证身份
+func whoami(ctx context.Context, _ interface{}) (interface{}, error) {
+	p, _ := PeerFromContext(ctx)
+	name := ""
+	if p.Principal != nil {
+		name = p.Principal.Name
+	}
+	return []string{p.CommonName(), name}, nil
+}
+
+func TestTLSRoundTrip(t *testing.T) {
+	serverCert, serverPool := selfSigned(t, "server")
+	clientCert, clientPool := selfSigned(t, "client-1")
+	rogueCert, _ := selfSigned(t, "admin") // 未被服务端信任
+
+	tests := []struct {
+		name       string
+		clientAuth tls.ClientAuthType
+		auth       Authenticator
+		clientCert *tls.Certificate
+		wantCN     string
+		wantErr    error // 非nil时期望连接失败
+		wantDial   bool  // 期望TLS握手本身失败
+	}{
+		{name: "server only", clientAuth: tls.NoClientCert, wantCN: ""},
+		{name: "mutual", clientAuth: tls.RequireAndVerifyClientCert, clientCert: &clientCert, wantCN: "client-1"},
+		{name: "mutual missing cert", clientAuth: tls.RequireAndVerifyClientCert, wantDial: true},
+		{name: "mutual untrusted cert", clientAuth: tls.RequireAndVerifyClientCert, clientCert: &rogueCert, wantDial: true},
+		{name: "mtls auth", clientAuth: tls.VerifyClientCertIfGiven, auth: MTLSAuthenticator{}, clientCert: &clientCert, wantCN: "client-1"},
+		{name: "requested cert is not verified", clientAuth: tls.RequestClientCert, clientCert: &rogueCert, wantCN: ""},
+		{name: "unverified cert rejected by mtls auth", clientAuth: tls.RequireAnyClientCert, auth: MTLSAuthenticator{}, clientCert: &rogueCert, wantErr: ErrUnauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sys := NewActorSystem()
+			_, _ = sys.RegisterContextActor("whoami", whoami)
+			opts := []ServerOption{WithServerTLS(&tls.Config{
+				Certificates: []tls.Certificate{serverCert},
+				ClientAuth:   tt.clientAuth,
+				ClientCAs:    clientPool,
+			})}
+			if tt.auth != nil {
+				opts = append(opts, WithAuthenticator(tt.auth))
+			}
+			_, addr := startServer(t, sys, opts...)
+
+			cfg := &tls.Config{RootCAs: serverPool}
+			if cert := tt.clientCert; cert != nil {
+				// 无论服务端要求哪些CA都发送该证书，模拟伪造身份的客户端
+				cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+					return cert, nil
+				}
+			}
+			ref, err := NewRemoteActorRef("whoami", addr, WithTLS(cfg))
+			switch {
+			case tt.wantDial:
+				if err == nil {
+					ref.(*remoteActorRef).Close()
+					t.Fatal("expected handshake to fail")
+				}
+				return
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				return
+			case err != nil:
+				t.Fatal(err)
+			}
+			defer ref.(*remoteActorRef).Close()
+
+			var got []string
+			if err := ref.Request(testContext(t), nil, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got[0] != tt.wantCN {
+				t.Fatalf("common name %q, want %q", got[0], tt.wantCN)
+			}
+			if tt.auth != nil && got[1] != tt.wantCN {
+				t.Fatalf("principal %q, want %q", got[1], tt.wantCN)
+			}
+		})
+	}
+}
+
+func TestTLSRejectsUntrustedServer(t *testing.T) {
+	serverCert, _ := selfSigned(t, "server")
+	_, otherPool := selfSigned(t, "other")
+	sys := NewActorSystem()
+	_, addr := startServer(t, sys, WithServerTLS(&tls.Config{Certificates: []tls.Certificate{serverCert}}))
+	if ref, err := NewRemoteActorRef("x", addr, WithTLS(&tls.Config{RootCAs: otherPool})); err == nil {
+		ref.(*remoteActorRef).Close()
+		t.Fatal("expected certificate verification to fail")
+	}
+}
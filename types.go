@@ -9,6 +9,8 @@ var (
     ErrActorNotFound = errors.New("actor not found")
     ErrMailboxFull   = errors.New("actor mailbox is full")
     ErrActorStopped  = errors.New("actor is stopped")
+    ErrServerBusy    = errors.New("server is busy")
+    ErrActorExists   = errors.New("actor already exists")
 )
 
 // MessageType 定义消息类型
@@ -24,21 +26,43 @@ type Message struct {
     Payload interface{}     // 消息内容
     ReplyTo chan Response   `json:"-"` // 响应通道
     Context context.Context `json:"-"` // 消息上下文
+    
+    onReply func(Response) // 响应回调，设置后代替ReplyTo，由actor所在goroutine调用
+}
+
+// expectsReply 消息是否需要响应
+func (m Message) expectsReply() bool {
+    return m.ReplyTo != nil || m.onReply != nil
+}
+
+// reply 发送响应
+func (m Message) reply(resp Response) {
+    if m.onReply != nil {
+        m.onReply(resp)
+        return
+    }
+    if m.ReplyTo != nil {
+        m.ReplyTo <- resp
+    }
 }
 
 // Response 表示一个响应
 type Response struct {
     Data  interface{} // 响应数据
-    Error string      // 错误信息
+    Error *Error      // 错误信息，成功时为nil
 }
 
-// remoteMessage 表示一个远程消息
+// remoteMessage 表示一个远程消息，类型与ID由帧头携带
 type remoteMessage struct {
-    Type    MessageType `json:"type"`
-    Id      int64       `json:"id"`
-    Target  string      `json:"target"`
-    Payload interface{} `json:"payload"`
-    Error   string      `json:"error,omitempty"`
+    Target      string `json:"target,omitempty"`
+    PayloadType string `json:"payload_type,omitempty"` // 负载的注册类型名称
+    Payload     []byte `json:"payload,omitempty"`      // 编码后的负载
+    Error       *Error `json:"error,omitempty"`
+    Timeout     int64  `json:"timeout,omitempty"`      // 发送时剩余的超时时间(纳秒)，0表示没有截止时间
+    Sender      string `json:"sender,omitempty"`       // 至少一次投递的发送方标识
+    Seq         uint64 `json:"seq,omitempty"`          // 发送方内的消息序号
+    Window      int64  `json:"window,omitempty"`       // 流式请求或上传流的初始接收窗口
+    Kind        string `json:"kind,omitempty"`         // 远程创建actor时的类型名称
 }
 
 // ActorRef 表示actor的引用
@@ -49,6 +73,16 @@ type ActorRef interface {
     // Tell 发送单向消息，不等待响应
     Tell(msg interface{}) error
     
+    // TellWithAck 发送单向消息并等待投递确认，投递失败时返回原因，
+    // 例如 ErrActorNotFound、ErrMailboxFull、ErrActorStopped
+    TellWithAck(ctx context.Context, msg interface{}) error
+    
+    // RequestStream 发送流式请求，actor通过 ResponseStreamFromContext 逐块返回响应
+    RequestStream(ctx context.Context, req interface{}) (*Stream, error)
+    
+    // RequestUpload 打开上传流，actor收到的消息负载为 *Stream，按发送顺序逐块读取后回复一次响应
+    RequestUpload(ctx context.Context) (UploadStream, error)
+    
     // ID 获取Actor的ID
     ID() string
     
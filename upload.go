@@ -0,0 +1,153 @@
+package actor
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// UploadStream 调用方向actor逐块发送请求的上传流
+// actor收到负载为 *Stream 的消息，通过 Next/Recv 按发送顺序读取分块，读到 io.EOF 后回复一次响应
+type UploadStream interface {
+	// Send 发送一个分块，actor的接收窗口已满时阻塞，actor已回复或上传已取消时返回错误
+	Send(chunk interface{}) error
+
+	// CloseAndRecv 结束发送并等待actor的响应
+	CloseAndRecv(resp interface{}) error
+
+	// Cancel 中止上传，actor读取分块时收到 context.Canceled
+	Cancel()
+}
+
+// localUploadStream 本地actor的上传流，分块直接写入actor读取的Stream
+type localUploadStream struct {
+	ctx    context.Context
+	stream *Stream
+	reply  chan Response
+	ended  atomic.Bool
+}
+
+func (u *localUploadStream) Send(chunk interface{}) error {
+	if u.ended.Load() {
+		return ErrStreamClosed
+	}
+	return u.stream.push(chunk)
+}
+
+func (u *localUploadStream) CloseAndRecv(resp interface{}) error {
+	u.ended.Store(true)
+	u.stream.end(nil)
+
+	select {
+	case res := <-u.reply:
+		if res.Error != nil {
+			return res.Error
+		}
+		return assignResult(res.Data, resp)
+	case <-u.ctx.Done():
+		u.Cancel()
+		return u.ctx.Err()
+	}
+}
+
+func (u *localUploadStream) Cancel() {
+	u.ended.Store(true)
+	u.stream.end(context.Canceled)
+}
+
+// newUploadInbox 创建actor读取上传分块的Stream，每消费半个窗口调用一次grant归还额度
+func newUploadInbox(ctx context.Context, window int, grant func(n int)) *Stream {
+	st := newStream(ctx, window)
+	if grant == nil {
+		return st
+	}
+	var consumed atomic.Int64
+	batch := int64(window / 2)
+	if batch < 1 {
+		batch = 1
+	}
+	st.onConsume = func() {
+		if n := consumed.Add(1); n >= batch {
+			consumed.Add(-n)
+			grant(int(n))
+		}
+	}
+	return st
+}
+
+// remoteUploadStream 通过ActorServer发送的上传流，受actor端接收窗口限制
+type remoteUploadStream struct {
+	ref   *remoteActorRef
+	id    int64
+	ctx   context.Context
+	flow  *flowControl
+	reply chan Response
+	ended atomic.Bool
+
+	finished   chan struct{} // 收到响应、取消或连接关闭后关闭
+	finishOnce sync.Once
+}
+
+func (u *remoteUploadStream) Send(chunk interface{}) error {
+	if u.ended.Load() {
+		return ErrStreamClosed
+	}
+	if err := u.flow.acquire(u.ctx, u.finished); err != nil {
+		select {
+		case <-u.finished:
+			return ErrStreamClosed
+		default:
+		}
+		return err
+	}
+	m, err := newRemoteMessage(u.ref.wire.codec, "", chunk)
+	if err != nil {
+		return err
+	}
+	f, err := u.ref.wire.encodeMessage(frameUploadChunk, uint64(u.id), m)
+	if err != nil {
+		return err
+	}
+	return u.ref.out.enqueueContext(u.ctx, f)
+}
+
+func (u *remoteUploadStream) CloseAndRecv(resp interface{}) error {
+	if !u.ended.Swap(true) {
+		f, err := u.ref.wire.encodeMessage(frameUploadEnd, uint64(u.id), &remoteMessage{})
+		if err == nil {
+			err = u.ref.out.enqueueContext(u.ctx, f)
+		}
+		if err != nil {
+			u.Cancel()
+			return err
+		}
+	}
+
+	select {
+	case res := <-u.reply:
+		if res.Error != nil {
+			return res.Error
+		}
+		return assignResult(res.Data, resp)
+	case <-u.ctx.Done():
+		u.Cancel()
+		return u.ctx.Err()
+	}
+}
+
+func (u *remoteUploadStream) Cancel() {
+	u.ended.Store(true)
+	if ch, ok := u.ref.takePending(u.id); ok {
+		u.ref.sendControl(frame{Type: frameCancel, ID: uint64(u.id)})
+		ch <- Response{Error: toError(context.Canceled)}
+		close(ch)
+	}
+	u.ref.takeUpload(u.id)
+	u.finish()
+}
+
+func (u *remoteUploadStream) finish() {
+	u.finishOnce.Do(func() {
+		close(u.finished)
+	})
+}
@@ -0,0 +1,93 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// sumActor 按顺序读取上传的整数并返回总和
+func sumActor(started chan<- struct{}, aborted chan<- error) ContextHandler {
+	return func(ctx context.Context, msg interface{}) (interface{}, error) {
+		st, ok := msg.(*Stream)
+		if !ok {
+			return nil, nil
+		}
+		sum := 0
+		for expect := 0; ; expect++ {
+			var x int
+			err := st.Recv(&x)
+			if err == io.EOF {
+				return sum, nil
+			}
+			if err != nil {
+				aborted <- err
+				return nil, err
+			}
+			if x != expect {
+				return nil, errors.New("chunks out of order")
+			}
+			if x == 0 {
+				started <- struct{}{}
+			}
+			sum += x
+		}
+	}
+}
+
+func TestRequestUpload(t *testing.T) {
+	sys := NewActorSystem()
+	started := make(chan struct{}, 1)
+	aborted := make(chan error, 1)
+	local, _ := sys.RegisterContextActor("sum", sumActor(started, aborted))
+	srv, addr := startServer(t, sys)
+	remote := dial(t, "sum", addr, WithCodecs(CodecGob), WithStreamWindow(8))
+
+	for _, tt := range []struct {
+		name string
+		ref  ActorRef
+	}{{"local", local}, {"remote", remote}} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := testContext(t)
+			up, err := tt.ref.RequestUpload(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 2000; i++ {
+				if err := up.Send(i); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var sum int
+			if err := up.CloseAndRecv(&sum); err != nil || sum != 1999000 {
+				t.Fatal(sum, err)
+			}
+			<-started
+
+			// 调用方取消后actor读取分块时收到取消错误
+			up, err = tt.ref.RequestUpload(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := up.Send(0); err != nil {
+				t.Fatal(err)
+			}
+			<-started
+			up.Cancel()
+			select {
+			case err := <-aborted:
+				if !errors.Is(err, context.Canceled) {
+					t.Fatalf("actor aborted with %v, want context.Canceled", err)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("actor not aborted")
+			}
+			if err := up.Send(1); err == nil {
+				t.Fatal("send after cancel succeeded")
+			}
+		})
+	}
+	eventually(t, "in-flight quota released", func() bool { return srv.InFlight() == 0 })
+}